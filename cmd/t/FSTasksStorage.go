@@ -1,5 +1,3 @@
-//go:build !tsqlite
-
 package main
 
 import (
@@ -10,26 +8,30 @@ import (
 	storage "github.com/thek4n/t/internal/storage"
 )
 
-const T_BASE_DIR = ".t"
+func initFSTaskStorage(tBasePath string, order storage.SortOrder, namespaceSorts map[string]storage.SortOrder) storage.TasksStorage {
+	return &storage.FSTasksStorage{TBaseDir: tBasePath, Sort: order.By, Reverse: order.Reverse, NamespaceSorts: namespaceSorts}
+}
 
-func initTaskStorage() storage.TasksStorage {
-	tBasePath, err := getBaseDir()
-	if err != nil {
-		die("%s", err.Error())
+func createNamespace(s storage.TasksStorage, namespace string) error {
+	fsStorage, isFS := asFSStorage(s)
+	if !isFS {
+		return nil
 	}
 
-	return &storage.FSTasksStorage{TBaseDir: tBasePath}
+	namespacePath := path.Join(fsStorage.TBaseDir, namespace)
+
+	return createDirectoryIfNotExists(namespacePath)
 }
 
-func createNamespace(namespace string) error {
-	tBasePath, err := getBaseDir()
-	if err != nil {
-		return err
+// Encrypted storage keeps namespaces plain, so directories of namespaces are managed as without encryption
+func asFSStorage(s storage.TasksStorage) (*storage.FSTasksStorage, bool) {
+	encrypted, isEncrypted := s.(*storage.EncryptedTasksStorage)
+	if isEncrypted {
+		s = encrypted.Unwrap()
 	}
 
-	namespacePath := path.Join(tBasePath, namespace)
-
-	return createDirectoryIfNotExists(namespacePath)
+	fsStorage, isFS := s.(*storage.FSTasksStorage)
+	return fsStorage, isFS
 }
 
 func createDirectoryIfNotExists(directory string) error {
@@ -51,18 +53,19 @@ func createDirectoryIfNotExists(directory string) error {
 }
 
 func cleanupEmptyNamespaces(s storage.TasksStorage) error {
-	namespaces, err := s.GetNamespaces()
-	if err != nil {
-		return err
+	fsStorage, isFS := asFSStorage(s)
+	if !isFS {
+		return nil
 	}
 
-	tBasePath, err := getBaseDir()
+	namespaces, err := s.GetNamespaces()
 	if err != nil {
 		return err
 	}
 
-	for _, ns := range namespaces {
-		err = removeEmptyDir(path.Join(tBasePath, ns))
+	// children first, so parent without tasks becomes empty before check
+	for i := len(namespaces) - 1; i >= 0; i-- {
+		err = removeEmptyDir(path.Join(fsStorage.TBaseDir, namespaces[i]))
 		if err != nil {
 			return err
 		}
@@ -92,12 +95,3 @@ func removeEmptyDir(path string) error {
 
 	return nil
 }
-
-func getBaseDir() (string, error) {
-	home := os.Getenv("HOME")
-	if home == "" {
-		return "", fmt.Errorf("HOME environment variable is invalid")
-	}
-
-	return path.Join(home, T_BASE_DIR), nil
-}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	handlers "github.com/thek4n/t/internal/handlers"
+	"github.com/thek4n/t/internal/storage"
+)
+
+// Hidden command used by completion scripts, not listed in COMMANDS
+const COMPLETE_COMMAND = "__complete"
+
+const BASH_COMPLETION = `_t_completion() {
+    local IFS=$'\n'
+    local candidates=($(t __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+    COMPREPLY=()
+    local candidate
+    for candidate in "${candidates[@]}"; do
+        COMPREPLY+=("${candidate%%$'\t'*}")
+    done
+}
+complete -F _t_completion t
+`
+
+const ZSH_COMPLETION = `#compdef t
+_t() {
+    local -a candidates
+    local line value
+    for line in "${(@f)$(t __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}"; do
+        [[ -z "$line" ]] && continue
+        value="${${line%%$'\t'*}//:/\\:}"
+        if [[ "$line" == *$'\t'* ]]; then
+            candidates+=("${value}:${line#*$'\t'}")
+        else
+            candidates+=("${value}")
+        fi
+    done
+    _describe 't' candidates
+}
+compdef _t t
+`
+
+const FISH_COMPLETION = `function __t_complete
+    set -l tokens (commandline -opc) (commandline -ct)
+    t __complete $tokens[2..-1] 2>/dev/null
+end
+complete -c t -f -a '(__t_complete)'
+`
+
+var COMPLETION_SCRIPTS = map[string]string{
+	"bash": BASH_COMPLETION,
+	"zsh":  ZSH_COMPLETION,
+	"fish": FISH_COMPLETION,
+}
+
+// Commands which take task indexes as arguments
+var INDEX_COMMANDS = map[string]bool{
+	"done":   true,
+	"d":      true,
+	"в":      true,
+	"delete": true,
+	"edit":   true,
+	"e":      true,
+	"у":      true,
+	"recur":  true,
+	"pri":    true,
+	"pin":    true,
+	"unpin":  true,
+	"order":  true,
+	"top":    true,
+	"bottom": true,
+	"start":  true,
+}
+
+func cmdCompletion(_ storage.TasksStorage, args []string, _ string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("%s", "Not enough args, expected bash, zsh or fish")
+	}
+
+	script, found := COMPLETION_SCRIPTS[args[0]]
+	if !found {
+		return fmt.Errorf("Unknown shell '%s', expected bash, zsh or fish", args[0])
+	}
+
+	_, err := fmt.Print(script)
+	return err
+}
+
+// Prints candidates for last word of args in format 'VALUE\tDESCRIPTION',
+// only commands if storage is nil
+func complete(s storage.TasksStorage, args []string) {
+	if len(args) < 1 {
+		args = []string{""}
+	}
+
+	current := args[len(args)-1]
+	previous := args[:len(args)-1]
+
+	var candidates [][2]string
+	namespace := getNamespace()
+
+	if len(previous) > 0 {
+		firstArgumentIsWord, _ := regexp.MatchString(`[a-zA-Z]+`, previous[0])
+		_, firstArgumentIsCommand := COMMANDS[previous[0]]
+		_, firstArgumentIsKeyCommand := KEY_COMMANDS[previous[0]]
+		firstArgumentIsCommand = firstArgumentIsCommand || firstArgumentIsKeyCommand
+		_, firstArgumentIsAlias := cfg.Aliases[previous[0]]
+		firstArgumentIsPlugin := !firstArgumentIsCommand && findPlugin(previous[0]) != ""
+		if firstArgumentIsWord && !firstArgumentIsCommand && !firstArgumentIsAlias && !firstArgumentIsPlugin {
+			namespace = previous[0]
+			previous = previous[1:]
+		}
+	}
+
+	switch {
+	case s == nil && len(previous) == 0: // encrypted tasks are locked
+		candidates = commandCandidates()
+
+	case s == nil:
+
+	case len(args) == 1:
+		candidates = append(candidates, commandCandidates()...)
+		candidates = append(candidates, namespaceCandidates(s)...)
+		candidates = append(candidates, indexCandidates(s, namespace)...)
+
+	case len(previous) == 0:
+		candidates = append(candidates, commandCandidates()...)
+		candidates = append(candidates, indexCandidates(s, namespace)...)
+
+	case INDEX_COMMANDS[previous[0]]:
+		candidates = indexCandidates(s, namespace)
+
+	case previous[0] == "get":
+		candidates = nameCandidates(s, namespace)
+
+	case (previous[0] == "ns" || previous[0] == "namespaces") && len(previous) == 1:
+		for _, subcommand := range []string{"rename", "merge", "rm"} {
+			candidates = append(candidates, [2]string{subcommand, ""})
+		}
+
+	case previous[0] == "ns" || previous[0] == "namespaces":
+		candidates = namespaceCandidates(s)
+
+	case previous[0] == "completion" && len(previous) == 1:
+		for _, shell := range []string{"bash", "zsh", "fish"} {
+			candidates = append(candidates, [2]string{shell, ""})
+		}
+	}
+
+	for _, candidate := range candidates {
+		if !strings.HasPrefix(candidate[0], current) {
+			continue
+		}
+
+		if candidate[1] == "" {
+			fmt.Println(candidate[0])
+			continue
+		}
+		fmt.Printf("%s\t%s\n", candidate[0], candidate[1])
+	}
+}
+
+func commandCandidates() [][2]string {
+	commands := make([]string, 0, len(COMMANDS)+len(KEY_COMMANDS)+len(cfg.Aliases))
+	for command := range COMMANDS {
+		if strings.HasPrefix(command, "-") {
+			continue
+		}
+		commands = append(commands, command)
+	}
+	for command := range KEY_COMMANDS {
+		commands = append(commands, command)
+	}
+	for alias := range cfg.Aliases {
+		if _, isCommand := COMMANDS[alias]; !isCommand {
+			commands = append(commands, alias)
+		}
+	}
+	sort.Strings(commands)
+
+	result := make([][2]string, 0, len(commands))
+	for _, command := range commands {
+		result = append(result, [2]string{command, ""})
+	}
+	for _, plugin := range pluginNames() {
+		result = append(result, [2]string{plugin, "plugin"})
+	}
+	return result
+}
+
+func namespaceCandidates(s storage.TasksStorage) [][2]string {
+	namespaces, err := s.GetNamespaces()
+	if err != nil {
+		return nil
+	}
+
+	result := make([][2]string, 0, len(namespaces))
+	for _, ns := range namespaces {
+		count, err := s.Count(ns)
+		if err != nil {
+			continue
+		}
+		result = append(result, [2]string{ns, fmt.Sprintf("namespace (%d)", count)})
+	}
+	return result
+}
+
+func indexCandidates(s storage.TasksStorage, namespace string) [][2]string {
+	tasks, err := handlers.ListTasks(namespace, s)
+	if err != nil {
+		return nil
+	}
+
+	result := make([][2]string, 0, len(tasks))
+	for i, tv := range tasks {
+		result = append(result, [2]string{fmt.Sprint(i + 1), tv.FormattedName})
+	}
+	return result
+}
+
+func nameCandidates(s storage.TasksStorage, namespace string) [][2]string {
+	tasks, err := s.GetSorted(namespace)
+	if err != nil {
+		return nil
+	}
+
+	result := make([][2]string, 0, len(tasks))
+	for _, task := range tasks {
+		result = append(result, [2]string{task, ""})
+	}
+	return result
+}
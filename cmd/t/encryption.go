@@ -0,0 +1,467 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	handlers "github.com/thek4n/t/internal/handlers"
+	storage "github.com/thek4n/t/internal/storage"
+)
+
+// Passphrase of encrypted tasks, used instead of prompt and agent
+const PASSPHRASE_ENV = "T_PASSPHRASE"
+
+// Hidden command which runs key cache agent, started by 'unlock'
+const AGENT_COMMAND = "__agent"
+
+const DEFAULT_UNLOCK_TIMEOUT = 8 * time.Hour
+
+// Commands of encryption run before storage is opened, so they don't need key
+var KEY_COMMANDS = map[string]func([]string) error{
+	"encrypt": cmdEncrypt,
+	"unlock":  cmdUnlock,
+	"lock":    cmdLock,
+}
+
+// Wraps storage by encryption if data directory is encrypted, prompts passphrase only if prompt is set.
+// Hooks don't get file with content of encrypted task
+func openEncrypted(s storage.TasksStorage, tBasePath string, prompt bool) (storage.TasksStorage, error) {
+	header, err := storage.ReadEncryptionHeader(tBasePath)
+	if err != nil || header == nil {
+		return s, err
+	}
+
+	key, err := encryptionKey(header, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	handlers.HookTaskFile = false
+	return storage.NewEncryptedStorage(s, key, header.Names)
+}
+
+// Key is derived from variable T_PASSPHRASE, then taken from agent, then derived from passphrase from prompt
+func encryptionKey(header *storage.EncryptionHeader, prompt bool) ([]byte, error) {
+	passphrase, found := os.LookupEnv(PASSPHRASE_ENV)
+	if found {
+		return header.DeriveKey(passphrase)
+	}
+
+	key, err := agentKey(header)
+	if err == nil {
+		return key, nil
+	}
+
+	if !prompt || !isTerminal(os.Stdin) {
+		return nil, fmt.Errorf("Tasks are encrypted, run 't unlock' or set %s", PASSPHRASE_ENV)
+	}
+
+	passphrase, err = readPassphrase("Passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+	return header.DeriveKey(passphrase)
+}
+
+func cmdEncrypt(args []string) error {
+	flags := flag.NewFlagSet("encrypt", flag.ContinueOnError)
+	names := flags.Bool("names", false, "encrypt names of tasks too")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	tBasePath, err := getBaseDir()
+	if err != nil {
+		return err
+	}
+
+	header, err := storage.ReadEncryptionHeader(tBasePath)
+	if err != nil {
+		return err
+	}
+	if header != nil {
+		return fmt.Errorf("Tasks in '%s' are already encrypted", tBasePath)
+	}
+
+	err = ensureNoTasks(initTaskStorage(false))
+	if err != nil {
+		return err
+	}
+
+	passphrase, found := os.LookupEnv(PASSPHRASE_ENV)
+	if !found {
+		passphrase, err = readNewPassphrase()
+		if err != nil {
+			return err
+		}
+	}
+
+	header, _, err = storage.NewEncryptionHeader(passphrase, *names)
+	if err != nil {
+		return err
+	}
+
+	err = storage.WriteEncryptionHeader(tBasePath, header)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Tasks in '%s' are encrypted, run 't unlock' to cache key\n", tBasePath)
+	return nil
+}
+
+// Existing tasks would stay plain, so encryption is set up only for data directory without tasks
+func ensureNoTasks(s storage.TasksStorage) error {
+	namespaces, err := s.GetNamespaces()
+	if err != nil {
+		return err
+	}
+
+	for _, namespace := range namespaces {
+		count, err := s.Count(namespace)
+		if err != nil {
+			return err
+		}
+
+		if count > 0 {
+			return fmt.Errorf("Encryption can be set up only for data directory without tasks, " +
+				"export tasks, run 't --dir NEW encrypt' and import them into NEW")
+		}
+	}
+
+	done, err := s.GetDone("", time.Time{})
+	if err != nil {
+		return err
+	}
+	if len(done) > 0 {
+		return fmt.Errorf("Encryption can be set up only for data directory without done tasks")
+	}
+
+	return nil
+}
+
+func readNewPassphrase() (string, error) {
+	passphrase, err := readPassphrase("New passphrase: ")
+	if err != nil {
+		return "", err
+	}
+
+	if passphrase == "" {
+		return "", fmt.Errorf("Passphrase is empty")
+	}
+
+	repeated, err := readPassphrase("Repeat passphrase: ")
+	if err != nil {
+		return "", err
+	}
+
+	if passphrase != repeated {
+		return "", fmt.Errorf("Passphrases don't match")
+	}
+	return passphrase, nil
+}
+
+// Reads line from stdin, echo of terminal is disabled by stty
+func readPassphrase(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+
+	if isTerminal(os.Stdin) {
+		stty("-echo")
+		defer func() {
+			stty("echo")
+			fmt.Fprintln(os.Stderr)
+		}()
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("Error read passphrase: %s", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func stty(arg string) {
+	cmd := exec.Command("stty", arg)
+	cmd.Stdin = os.Stdin
+	cmd.Run()
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Starts agent which keeps key in memory until timeout or 't lock', running agent is replaced
+func cmdUnlock(args []string) error {
+	flags := flag.NewFlagSet("unlock", flag.ContinueOnError)
+	timeout := flags.Duration("timeout", DEFAULT_UNLOCK_TIMEOUT, "forget key after timeout")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	header, err := requireEncryptionHeader()
+	if err != nil {
+		return err
+	}
+
+	passphrase, found := os.LookupEnv(PASSPHRASE_ENV)
+	if !found {
+		passphrase, err = readPassphrase("Passphrase: ")
+		if err != nil {
+			return err
+		}
+	}
+
+	key, err := header.DeriveKey(passphrase)
+	if err != nil {
+		return err
+	}
+
+	socketPath, err := agentSocketPath(header)
+	if err != nil {
+		return err
+	}
+
+	agentRequest(socketPath, "lock")
+	return startAgent(socketPath, key, *timeout)
+}
+
+func cmdLock(args []string) error {
+	header, err := requireEncryptionHeader()
+	if err != nil {
+		return err
+	}
+
+	socketPath, err := agentSocketPath(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = agentRequest(socketPath, "lock")
+	if err != nil {
+		return fmt.Errorf("No running agent")
+	}
+	return nil
+}
+
+func requireEncryptionHeader() (*storage.EncryptionHeader, error) {
+	tBasePath, err := getBaseDir()
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := storage.ReadEncryptionHeader(tBasePath)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, fmt.Errorf("Tasks in '%s' are not encrypted, run 't encrypt' first", tBasePath)
+	}
+	return header, nil
+}
+
+// Socket of agent is in directory accessible only by user, named by data directory
+func agentSocketPath(header *storage.EncryptionHeader) (string, error) {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = path.Join(os.TempDir(), fmt.Sprintf("t-%d", os.Getuid()))
+	} else {
+		dir = path.Join(dir, "t")
+	}
+
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("Directory of agent '%s' is not directory", dir)
+	}
+
+	// directory in shared temp can be created by other user before
+	if !isPrivateDir(info) {
+		return "", fmt.Errorf("Directory of agent '%s' must be owned and accessible only by user", dir)
+	}
+
+	return path.Join(dir, "agent-"+header.ID()+".sock"), nil
+}
+
+func agentKey(header *storage.EncryptionHeader) ([]byte, error) {
+	socketPath, err := agentSocketPath(header)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := agentRequest(socketPath, "key")
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := hex.DecodeString(response)
+	if err != nil {
+		return nil, err
+	}
+
+	return key, header.VerifyKey(key)
+}
+
+func agentRequest(socketPath string, request string) (string, error) {
+	conn, err := net.DialTimeout("unix", socketPath, time.Second)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(time.Second))
+
+	_, err = fmt.Fprintln(conn, request)
+	if err != nil {
+		return "", err
+	}
+
+	response, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(response), nil
+}
+
+// Runs agent as background process, key is passed by pipe, so it is not visible in arguments
+func startAgent(socketPath string, key []byte, timeout time.Duration) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(executable, AGENT_COMMAND, socketPath, timeout.String())
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	cmd.Stderr = os.Stderr // stdout is protocol, agent reports errors to terminal
+
+	err = cmd.Start()
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(stdin, hex.EncodeToString(key))
+	stdin.Close()
+	if err != nil {
+		return err
+	}
+
+	ready, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil || strings.TrimSpace(ready) != "ready" {
+		return fmt.Errorf("%s", "Error start agent")
+	}
+
+	fmt.Fprintf(os.Stderr, "Key is cached for %s\n", timeout)
+	return cmd.Process.Release()
+}
+
+// Serves key on socket until timeout or request 'lock', agent outlives terminal of 'unlock'.
+// Stdout only reports readiness, errors go to stderr
+func runAgent(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("%s", "Not enough args, expected SOCKET and TIMEOUT")
+	}
+
+	socketPath := args[0]
+	timeout, err := time.ParseDuration(args[1])
+	if err != nil {
+		return err
+	}
+
+	key, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return err
+	}
+	key = strings.TrimSpace(key)
+
+	signal.Ignore(syscall.SIGHUP, os.Interrupt)
+
+	err = os.Remove(socketPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(socketPath, 0600)
+	if err != nil {
+		listener.Close()
+		return err
+	}
+
+	fmt.Println("ready")
+	os.Stdout.Close()
+	os.Stderr.Close()
+
+	time.AfterFunc(timeout, func() {
+		listener.Close()
+	})
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return nil // listener closed by timeout
+		}
+
+		locked := serveAgentRequest(conn, key)
+		if locked {
+			return listener.Close()
+		}
+	}
+}
+
+// Returns true if agent is locked by request
+func serveAgentRequest(conn net.Conn, key string) bool {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(time.Second))
+
+	request, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	switch strings.TrimSpace(request) {
+	case "key":
+		fmt.Fprintln(conn, key)
+	case "lock":
+		fmt.Fprintln(conn, "ok")
+		return true
+	}
+	return false
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// Owner isn't available by os.FileInfo here, temporary directory is per user on Windows
+func isPrivateDir(info os.FileInfo) bool {
+	return true
+}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// Directory is owned and accessible only by user
+func isPrivateDir(info os.FileInfo) bool {
+	stat, isStat := info.Sys().(*syscall.Stat_t)
+	return isStat && int(stat.Uid) == os.Getuid() && info.Mode().Perm() == 0700
+}
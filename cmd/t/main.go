@@ -3,21 +3,35 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/thek4n/t/internal/config"
 	handlers "github.com/thek4n/t/internal/handlers"
 	"github.com/thek4n/t/internal/storage"
+	"github.com/thek4n/t/internal/tui"
 )
 
-const DEFAULT_NAMESPACE = "def"
 const ENVFILE = ".tns"
 
+var cfg = config.Default()
+
+// Sort orders from config, default and by namespace
+var sortOrder storage.SortOrder
+var namespaceSorts = map[string]storage.SortOrder{}
+
+// Global flags, must be before namespace and command
+var dataDirFlag string
+var colorFlag string
+
 var COMMANDS = map[string]func(storage.TasksStorage, []string, string) error{
 	"show": cmdShow,
 
@@ -25,12 +39,33 @@ var COMMANDS = map[string]func(storage.TasksStorage, []string, string) error{
 	"a":   cmdAdd,
 	"ф":   cmdAdd,
 
-	"done":   cmdDone,
-	"delete": cmdDone,
-	"d":      cmdDone,
-	"в":      cmdDone,
+	"done": cmdDone,
+	"d":    cmdDone,
+	"в":    cmdDone,
+
+	"delete": cmdDelete,
+
+	"log": cmdLog,
+
+	"recur": cmdRecur,
+
+	"pri": cmdPriority,
+
+	"pin":   cmdPin,
+	"unpin": cmdUnpin,
+
+	"order":  cmdOrder,
+	"top":    cmdTop,
+	"bottom": cmdBottom,
+
+	"start": cmdStart,
+	"stop":  cmdStop,
+	"time":  cmdTime,
+
+	"stats": cmdStats,
 
 	"edit": cmdEdit,
+	"e":    cmdEdit,
 	"у":    cmdEdit,
 
 	"get": cmdGet,
@@ -40,6 +75,13 @@ var COMMANDS = map[string]func(storage.TasksStorage, []string, string) error{
 
 	"all": cmdAll,
 
+	"tui": cmdTui,
+
+	"export": cmdExport,
+	"import": cmdImport,
+
+	"completion": cmdCompletion,
+
 	"-h":     cmdHelp,
 	"--help": cmdHelp,
 
@@ -50,12 +92,69 @@ var COMMANDS = map[string]func(storage.TasksStorage, []string, string) error{
 func main() {
 	osArgs := os.Args[1:] // reject program name
 
-	s := initTaskStorage()
+	if len(osArgs) > 0 && osArgs[0] == AGENT_COMMAND {
+		err := runAgent(osArgs[1:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error agent: %s\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	var err error
+	cfg, err = config.Load()
+	if err != nil {
+		die("%s", err)
+	}
+	applyConfig()
+
+	completing := len(osArgs) > 0 && osArgs[0] == COMPLETE_COMMAND
+	if completing {
+		osArgs = osArgs[1:]
+	}
+
+	osArgs, err = parseGlobalFlags(osArgs)
+	if err != nil {
+		die("%s", err)
+	}
+
+	colorMode := cfg.Color
+	if colorFlag != "" {
+		colorMode = colorFlag
+	}
+
+	err = handlers.SetColorMode(colorMode)
+	if err != nil {
+		die("%s", err)
+	}
+
+	if len(osArgs) > 0 && !completing {
+		keyCommand, isKeyCommand := KEY_COMMANDS[osArgs[0]]
+		if isKeyCommand {
+			err = keyCommand(osArgs[1:])
+			if err != nil {
+				die("%s", err)
+			}
+			os.Exit(0)
+		}
+	}
+
+	s := initTaskStorage(!completing)
+
+	if completing {
+		complete(s, osArgs)
+		os.Exit(0)
+	}
 
 	argsEmpty := len(osArgs) < 1
 	if argsEmpty {
 		namespace := getNamespace()
-		err := showTasks(s, namespace)
+		err = handlers.ValidateNamespace(namespace)
+		if err != nil {
+			die("%s", err)
+		}
+
+		err = showTasks(s, namespace)
 		if err != nil {
 			cleanupEmptyNamespaces(s)
 			die("Error show namespaces: %s", err)
@@ -63,19 +162,28 @@ func main() {
 		os.Exit(0)
 	}
 
+	osArgs = expandAlias(osArgs)
+
 	firstArgumentIsWord, _ := regexp.MatchString(`[a-zA-Z]+`, osArgs[0])
 	_, firstArgumentIsCommand := COMMANDS[osArgs[0]]
 
-	firstArgumentIsNamespace := firstArgumentIsWord && !firstArgumentIsCommand
+	firstArgumentIsPlugin := !firstArgumentIsCommand && findPlugin(osArgs[0]) != ""
+
+	firstArgumentIsNamespace := firstArgumentIsWord && !firstArgumentIsCommand && !firstArgumentIsPlugin
 
 	var namespace string
 	if firstArgumentIsNamespace {
 		namespace = osArgs[0]
-		osArgs = osArgs[1:] // reject namespace from args
+		osArgs = expandAlias(osArgs[1:]) // reject namespace from args
 	} else {
 		namespace = getNamespace()
 	}
 
+	err = handlers.ValidateNamespace(namespace)
+	if err != nil {
+		die("%s", err)
+	}
+
 	argsEmpty = len(osArgs) < 1
 	if argsEmpty {
 		err := showTasks(s, namespace)
@@ -86,14 +194,14 @@ func main() {
 		os.Exit(0)
 	}
 
-	err := createNamespace(namespace)
+	err = createNamespace(s, namespace)
 	if err != nil {
 		die("Error creating namespace: %s", err)
 	}
 
 	commandArgumentIsNumber, _ := regexp.MatchString(`[0-9]+`, osArgs[0])
 	if commandArgumentIsNumber {
-		err := createNamespace(namespace)
+		err := createNamespace(s, namespace)
 		if err != nil {
 			cleanupEmptyNamespaces(s)
 			die("Error creating namespace: %s", err)
@@ -105,7 +213,7 @@ func main() {
 			die("Error parse index")
 		}
 
-		err = handlers.ShowTaskContentByIndex(namespace, index, s)
+		err = handlers.ShowTaskContentByIndex(os.Stdout, namespace, index, s)
 		if err != nil {
 			cleanupEmptyNamespaces(s)
 			die("Error: %s", err)
@@ -117,10 +225,20 @@ func main() {
 
 	handler, found := COMMANDS[osArgs[0]]
 	if !found {
-		die("Command '%s' not found", osArgs[0])
+		pluginPath := findPlugin(osArgs[0])
+		if pluginPath == "" {
+			die("Command '%s' not found", osArgs[0])
+		}
+
+		code, err := runPlugin(pluginPath, osArgs[1:], namespace)
+		cleanupEmptyNamespaces(s)
+		if err != nil {
+			die("Error on plugin '%s': %s", osArgs[0], err)
+		}
+		os.Exit(code)
 	}
 
-	err = createNamespace(namespace)
+	err = createNamespace(s, namespace)
 	if err != nil {
 		cleanupEmptyNamespaces(s)
 		die("Error creating namespace: %s", err)
@@ -135,13 +253,104 @@ func main() {
 	os.Exit(0)
 }
 
+// Parses leading global flags like '--dir PATH' or '--dir=PATH', returns rest of args
+func parseGlobalFlags(args []string) ([]string, error) {
+	for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+		name, value, hasValue := strings.Cut(args[0], "=")
+
+		var target *string
+		switch name {
+		case "--dir":
+			target = &dataDirFlag
+		case "--color":
+			target = &colorFlag
+		default:
+			return args, nil
+		}
+
+		if !hasValue {
+			if len(args) < 2 {
+				return nil, fmt.Errorf("Flag %s requires value", name)
+			}
+			value = args[1]
+			args = args[1:]
+		}
+
+		*target = value
+		args = args[1:]
+	}
+
+	return args, nil
+}
+
+func applyConfig() {
+	var err error
+	sortOrder, err = storage.ParseSortOrder(cfg.Sort)
+	if err != nil {
+		die("Error in config: %s", err)
+	}
+
+	for namespace, sort := range cfg.Sorts {
+		namespaceSorts[namespace], err = storage.ParseSortOrder(sort)
+		if err != nil {
+			die("Error in config, sort of namespace '%s': %s", namespace, err)
+		}
+	}
+
+	handlers.LinesCountLimit = cfg.LinesCountLimit
+
+	for name, color := range cfg.Colors {
+		handlers.Colors[name] = color
+	}
+
+	hooksDir, err := cfg.Hooks()
+	home := os.Getenv("HOME")
+	if err == nil && cfg.HooksDir == "" && home != "" {
+		hooksDir = migrateLegacyHooksDir(path.Join(home, T_LEGACY_BASE_DIR, "hooks"), hooksDir)
+	}
+	if err == nil {
+		handlers.HooksDir = hooksDir
+	}
+
+	handlers.Editor = os.Getenv("EDITOR")
+	if cfg.Editor != "" {
+		handlers.Editor = cfg.Editor
+	}
+}
+
+// Replaces first argument by user defined alias from config, builtin commands can't be overwritten
+func expandAlias(args []string) []string {
+	if len(args) < 1 {
+		return args
+	}
+
+	if _, isCommand := COMMANDS[args[0]]; isCommand {
+		return args
+	}
+
+	alias, found := cfg.Aliases[args[0]]
+	if !found {
+		return args
+	}
+
+	aliasArgs, err := config.SplitArgs(alias)
+	if err != nil {
+		die("Error expanding alias '%s': %s", args[0], err)
+	}
+
+	return append(aliasArgs, args[1:]...)
+}
+
 func showTasks(s storage.TasksStorage, namespace string) error {
-	err := createNamespace(namespace)
+	err := createNamespace(s, namespace)
 	if err != nil {
 		return err
 	}
 
-	return handlers.ShowTasks(namespace, s)
+	if cfg.Recursive {
+		return handlers.ShowTasksWithChildren(os.Stdout, namespace, storage.SortOrder{}, s)
+	}
+	return handlers.ShowTasks(os.Stdout, namespace, s)
 }
 
 func showVersion() error {
@@ -149,8 +358,98 @@ func showVersion() error {
 	return err
 }
 
-func cmdShow(s storage.TasksStorage, _ []string, namespace string) error {
-	return handlers.ShowTasks(namespace, s)
+func cmdShow(s storage.TasksStorage, args []string, namespace string) error {
+	flags := flag.NewFlagSet("show", flag.ContinueOnError)
+	format := flags.String("format", "", "text/template format or name of format from config")
+	recursive := flags.Bool("r", cfg.Recursive, "show tasks of child namespaces too")
+	asJSON := flags.Bool("json", false, "show tasks as json array")
+	sortBy := flags.String("sort", "", "sort by "+strings.Join(storage.SORTS, ", ")+", indexes stay from default order")
+	reverse := flags.Bool("reverse", false, "reverse order")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	order, err := showOrder(namespace, *sortBy, *reverse)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		namespaces := []string{namespace}
+		if *recursive {
+			namespaces, err = handlers.WithChildren(namespace, s)
+			if err != nil {
+				return err
+			}
+		}
+		return handlers.ShowTasksJSON(os.Stdout, namespaces, order, s)
+	}
+
+	if *format == "" && *recursive {
+		return handlers.ShowTasksWithChildren(os.Stdout, namespace, order, s)
+	}
+
+	if *format == "" {
+		return handlers.ShowTasksSorted(os.Stdout, namespace, order, s)
+	}
+
+	tmpl, err := resolveFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	namespaces := []string{namespace}
+	if *recursive {
+		namespaces, err = handlers.WithChildren(namespace, s)
+		if err != nil {
+			return err
+		}
+	}
+
+	return handlers.ShowTasksFormatted(os.Stdout, namespaces, tmpl, order, s)
+}
+
+// Returns order of show flags, empty order if no flags, so pinned and manually ordered tasks keep their places
+func showOrder(namespace string, sortBy string, reverse bool) (storage.SortOrder, error) {
+	if sortBy == "" && !reverse {
+		return storage.SortOrder{}, nil
+	}
+
+	order, found := namespaceSorts[namespace]
+	if !found {
+		order = sortOrder
+	}
+
+	if sortBy != "" {
+		var err error
+		order, err = storage.ParseSortOrder(sortBy)
+		if err != nil {
+			return storage.SortOrder{}, err
+		}
+
+		if order.By == storage.SORT_CREATED && getBackend() == BACKEND_FS {
+			fmt.Fprintf(os.Stderr, "Warning: fs backend keeps only time of modification, tasks are sorted by updated\n")
+		}
+	}
+
+	order.Reverse = order.Reverse != reverse
+	return order, nil
+}
+
+// Returns named format from config or format itself if it is template
+func resolveFormat(format string) (string, error) {
+	named, found := cfg.Formats[format]
+	if found {
+		return named, nil
+	}
+
+	if !strings.Contains(format, "{{") {
+		return "", fmt.Errorf("Format '%s' not found in config", format)
+	}
+
+	return format, nil
 }
 
 func cmdAdd(s storage.TasksStorage, args []string, namespace string) error {
@@ -176,6 +475,24 @@ func cmdDone(s storage.TasksStorage, args []string, namespace string) error {
 		return fmt.Errorf("Error parse indexes: %s", err)
 	}
 
+	err = handlers.CompleteTasksByIndexes(namespace, indexes, s)
+	if err != nil {
+		return fmt.Errorf("Error completing task: %s", err)
+	}
+
+	return nil
+}
+
+func cmdDelete(s storage.TasksStorage, args []string, namespace string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("%s", "Not enough args")
+	}
+
+	indexes, err := atoiIndexes(args)
+	if err != nil {
+		return fmt.Errorf("Error parse indexes: %s", err)
+	}
+
 	err = handlers.DeleteTasksByIndexes(namespace, indexes, s)
 	if err != nil {
 		return fmt.Errorf("Error deleting task: %s", err)
@@ -184,6 +501,70 @@ func cmdDone(s storage.TasksStorage, args []string, namespace string) error {
 	return nil
 }
 
+func cmdLog(s storage.TasksStorage, args []string, namespace string) error {
+	flags := flag.NewFlagSet("log", flag.ContinueOnError)
+	flags.Bool("done", true, "show completed tasks")
+	all := flags.Bool("all", false, "show tasks from all namespaces")
+	rawSince := flags.String("since", "", "show tasks completed since duration ago (7d, 2w, 12h) or date (2006-01-02)")
+	asJSON := flags.Bool("json", false, "show completed tasks as json array")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	var since time.Time
+	if *rawSince != "" {
+		since, err = parseSince(*rawSince, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+
+	if *all {
+		namespace = ""
+	}
+
+	if *asJSON {
+		return handlers.ShowDoneLogJSON(os.Stdout, namespace, since, s)
+	}
+
+	return handlers.ShowDoneLog(os.Stdout, namespace, since, s)
+}
+
+// Parses duration ago like 7d, 2w, 12h or date like 2006-01-02
+func parseSince(since string, now time.Time) (time.Time, error) {
+	date, err := time.ParseInLocation("2006-01-02", since, time.Local)
+	if err == nil {
+		return date, nil
+	}
+
+	units := map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	}
+
+	for suffix, unit := range units {
+		count, found := strings.CutSuffix(since, suffix)
+		if !found {
+			continue
+		}
+
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("Error parse since '%s': %s", since, err)
+		}
+		return now.Add(-time.Duration(n) * unit), nil
+	}
+
+	duration, err := time.ParseDuration(since)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Error parse since '%s': expected duration like 7d, 2w, 12h or date like 2006-01-02", since)
+	}
+
+	return now.Add(-duration), nil
+}
+
 func atoiIndexes(indexes []string) ([]int, error) {
 	var res []int
 
@@ -216,12 +597,207 @@ func cmdEdit(s storage.TasksStorage, args []string, namespace string) error {
 	return nil
 }
 
+func cmdRecur(s storage.TasksStorage, args []string, namespace string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("%s", "Not enough args")
+	}
+
+	index, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("Error parse index %s: %s", args[0], err)
+	}
+
+	if len(args) == 1 {
+		return handlers.ShowRecurrenceByIndex(os.Stdout, namespace, index, s)
+	}
+
+	err = handlers.SetRecurrenceByIndex(namespace, index, strings.Join(args[1:], " "), s)
+	if err != nil {
+		return fmt.Errorf("Error setting recurrence: %s", err)
+	}
+
+	return nil
+}
+
+func cmdPriority(s storage.TasksStorage, args []string, namespace string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("%s", "Not enough args")
+	}
+
+	index, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("Error parse index %s: %s", args[0], err)
+	}
+
+	err = handlers.SetPriorityByIndex(namespace, index, args[1], s)
+	if err != nil {
+		return fmt.Errorf("Error setting priority: %s", err)
+	}
+
+	return nil
+}
+
+func cmdPin(s storage.TasksStorage, args []string, namespace string) error {
+	return pinTasks(s, args, namespace, true)
+}
+
+func cmdUnpin(s storage.TasksStorage, args []string, namespace string) error {
+	return pinTasks(s, args, namespace, false)
+}
+
+func pinTasks(s storage.TasksStorage, args []string, namespace string, pinned bool) error {
+	if len(args) < 1 {
+		return fmt.Errorf("%s", "Not enough args")
+	}
+
+	indexes, err := atoiIndexes(args)
+	if err != nil {
+		return fmt.Errorf("Error parse indexes: %s", err)
+	}
+
+	err = handlers.PinTasksByIndexes(namespace, indexes, pinned, s)
+	if err != nil {
+		return fmt.Errorf("Error pinning task: %s", err)
+	}
+
+	return nil
+}
+
+func cmdOrder(s storage.TasksStorage, args []string, namespace string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("%s", "Not enough args, expected INDEX and POSITION")
+	}
+
+	indexes, err := atoiIndexes(args[:2])
+	if err != nil {
+		return fmt.Errorf("Error parse index: %s", err)
+	}
+
+	return orderTask(s, namespace, indexes[0], indexes[1])
+}
+
+func cmdTop(s storage.TasksStorage, args []string, namespace string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("%s", "Not enough args")
+	}
+
+	index, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("Error parse index %s: %s", args[0], err)
+	}
+
+	return orderTask(s, namespace, index, 1)
+}
+
+func cmdBottom(s storage.TasksStorage, args []string, namespace string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("%s", "Not enough args")
+	}
+
+	index, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("Error parse index %s: %s", args[0], err)
+	}
+
+	count, err := s.Count(namespace)
+	if err != nil {
+		return err
+	}
+
+	return orderTask(s, namespace, index, count)
+}
+
+func orderTask(s storage.TasksStorage, namespace string, index int, position int) error {
+	err := handlers.OrderTaskByIndex(namespace, index, position, s)
+	if err != nil {
+		return fmt.Errorf("Error ordering task: %s", err)
+	}
+
+	return nil
+}
+
+func cmdStart(s storage.TasksStorage, args []string, namespace string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("%s", "Not enough args")
+	}
+
+	index, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("Error parse index %s: %s", args[0], err)
+	}
+
+	err = handlers.StartTimerByIndex(namespace, index, s)
+	if err != nil {
+		return fmt.Errorf("Error starting timer: %s", err)
+	}
+
+	return nil
+}
+
+func cmdStop(s storage.TasksStorage, _ []string, _ string) error {
+	return handlers.StopTimer(os.Stdout, s)
+}
+
+func cmdTime(s storage.TasksStorage, args []string, namespace string) error {
+	flags := flag.NewFlagSet("time", flag.ContinueOnError)
+	all := flags.Bool("all", false, "show time from all namespaces")
+	rawSince := flags.String("since", "", "show time tracked since duration ago (7d, 2w, 12h) or date (2006-01-02)")
+	by := flags.String("by", handlers.TIME_BY_TASK, "group time by "+strings.Join(handlers.TIME_GROUPS, ", "))
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	var since time.Time
+	if *rawSince != "" {
+		since, err = parseSince(*rawSince, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+
+	// grouping by namespace makes sense only for all namespaces
+	if *all || *by == handlers.TIME_BY_NAMESPACE {
+		namespace = ""
+	}
+
+	return handlers.ShowTimeReport(os.Stdout, namespace, since, *by, s)
+}
+
+// Statistics of current namespace with children by default, positional NS overrides it
+func cmdStats(s storage.TasksStorage, args []string, namespace string) error {
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		namespace, args = args[0], args[1:]
+
+		err := handlers.ValidateNamespace(namespace)
+		if err != nil {
+			return err
+		}
+	}
+
+	flags := flag.NewFlagSet("stats", flag.ContinueOnError)
+	all := flags.Bool("all", false, "show statistics of all namespaces")
+	period := flags.String("by", handlers.PERIOD_WEEK, "count created and done tasks by day or week")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *all {
+		namespace = ""
+	}
+
+	return handlers.ShowStats(os.Stdout, namespace, *period, s)
+}
+
 func cmdGet(s storage.TasksStorage, args []string, namespace string) error {
 	if len(args) < 1 {
 		return fmt.Errorf("%s", "Not enough args")
 	}
 
-	err := handlers.ShowTaskContentByName(namespace, args[0], s)
+	err := handlers.ShowTaskContentByName(os.Stdout, namespace, args[0], s)
 	if err != nil {
 		return fmt.Errorf("Error reading task: %s", err)
 	}
@@ -229,21 +805,199 @@ func cmdGet(s storage.TasksStorage, args []string, namespace string) error {
 	return nil
 }
 
-func cmdNamespaces(s storage.TasksStorage, _ []string, _ string) error {
-	err := handlers.ShowNamespaces(s)
+func cmdNamespaces(s storage.TasksStorage, args []string, _ string) error {
+	if len(args) < 1 {
+		err := handlers.ShowNamespaces(os.Stdout, s)
+		if err != nil {
+			return fmt.Errorf("Error reading namespace: %s", err)
+		}
+		return nil
+	}
+
+	subcommand, args := args[0], args[1:]
+
+	switch subcommand {
+	case "--json":
+		return handlers.ShowNamespacesJSON(os.Stdout, s)
+
+	case "rename":
+		if len(args) < 2 {
+			return fmt.Errorf("%s", "Not enough args, expected OLD and NEW")
+		}
+		return handlers.RenameNamespace(args[0], args[1], s)
+
+	case "merge":
+		if len(args) < 2 {
+			return fmt.Errorf("%s", "Not enough args, expected SRC and DST")
+		}
+		renamed, err := handlers.MergeNamespaces(args[0], args[1], s)
+		if err != nil {
+			return err
+		}
+
+		handlers.ShowRenamedTasks(os.Stdout, renamed)
+		return nil
+
+	case "rm":
+		force := false
+		var namespaces []string
+		for _, arg := range args {
+			if arg == "--force" || arg == "-f" {
+				force = true
+				continue
+			}
+			namespaces = append(namespaces, arg)
+		}
+
+		if len(namespaces) < 1 {
+			return fmt.Errorf("%s", "Not enough args, expected NS")
+		}
+
+		for _, ns := range namespaces {
+			err := handlers.DeleteNamespace(ns, force, s)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("Unknown subcommand '%s', expected rename, merge or rm", subcommand)
+}
+
+func cmdAll(s storage.TasksStorage, args []string, _ string) error {
+	flags := flag.NewFlagSet("all", flag.ContinueOnError)
+	format := flags.String("format", "", "text/template format or name of format from config")
+	asJSON := flags.Bool("json", false, "show tasks as json array")
+
+	err := flags.Parse(args)
 	if err != nil {
-		return fmt.Errorf("Error reading namespace: %s", err)
+		return err
+	}
+
+	if *asJSON {
+		return handlers.ShowAllTasksJSON(os.Stdout, s)
+	}
+
+	if *format == "" {
+		return handlers.ShowAllTasksFromAllNamespaces(os.Stdout, s)
+	}
+
+	tmpl, err := resolveFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	return handlers.ShowAllTasksFormatted(os.Stdout, tmpl, s)
+}
+
+func cmdExport(s storage.TasksStorage, args []string, namespace string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	markdown := flags.Bool("markdown", false, "export as markdown")
+	todotxt := flags.Bool("todotxt", false, "export in todo.txt format")
+	ics := flags.Bool("ics", false, "export as iCalendar VTODO components")
+	csv := flags.Bool("csv", false, "export as csv table")
+	includeDeleted := flags.Bool("include-deleted", false, "export deleted tasks to csv, sqlite only")
+	all := flags.Bool("all", false, "export all namespaces")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	namespaces := []string{namespace}
+	if flags.NArg() > 0 {
+		namespaces = flags.Args()
+	}
+
+	for _, ns := range namespaces {
+		err = handlers.ValidateNamespace(ns)
+		if err != nil {
+			return err
+		}
+	}
+
+	if *all {
+		namespaces, err = s.GetNamespaces()
+		if err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case *markdown:
+		return handlers.ExportMarkdown(os.Stdout, namespaces, s)
+	case *todotxt:
+		return handlers.ExportTodoTxt(os.Stdout, namespaces, s)
+	case *ics:
+		return handlers.ExportIcs(os.Stdout, namespaces, s)
+	case *csv:
+		return handlers.ExportCsv(os.Stdout, namespaces, *includeDeleted, s)
+	}
+
+	return fmt.Errorf("%s", "Export format required: --markdown, --todotxt, --ics or --csv")
+}
+
+func cmdImport(s storage.TasksStorage, args []string, namespace string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	markdown := flags.String("markdown", "", "import markdown file, '-' for stdin")
+	todotxt := flags.String("todotxt", "", "import todo.txt file, '-' for stdin")
+	ics := flags.String("ics", "", "import iCalendar file, '-' for stdin")
+	taskwarrior := flags.String("taskwarrior", "", "import output of 'task export', '-' for stdin")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *markdown != "":
+		return importFile(*markdown, func(r io.Reader) (handlers.ImportResult, error) {
+			return handlers.ImportMarkdown(r, namespace, s)
+		})
+	case *todotxt != "":
+		return importFile(*todotxt, func(r io.Reader) (handlers.ImportResult, error) {
+			return handlers.ImportTodoTxt(r, namespace, s)
+		})
+	case *ics != "":
+		return importFile(*ics, func(r io.Reader) (handlers.ImportResult, error) {
+			return handlers.ImportIcs(r, namespace, s)
+		})
+	case *taskwarrior != "":
+		return importFile(*taskwarrior, func(r io.Reader) (handlers.ImportResult, error) {
+			return handlers.ImportTaskwarrior(r, namespace, s)
+		})
+	}
+
+	return fmt.Errorf("%s", "Import format required: --markdown FILE, --todotxt FILE, --ics FILE or --taskwarrior FILE")
+}
+
+func importFile(filename string, importer func(io.Reader) (handlers.ImportResult, error)) error {
+	r := io.Reader(os.Stdin)
+	if filename != "-" {
+		file, err := os.Open(filename)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	result, err := importer(r)
+	if err != nil {
+		return err
 	}
 
+	handlers.ShowImportResult(os.Stdout, result)
 	return nil
 }
 
-func cmdAll(s storage.TasksStorage, _ []string, _ string) error {
-	return handlers.ShowAllTasksFromAllNamespaces(s)
+func cmdTui(s storage.TasksStorage, _ []string, namespace string) error {
+	return tui.Run(namespace, s)
 }
 
 func cmdHelp(_ storage.TasksStorage, _ []string, _ string) error {
-	return handlers.ShowHelp()
+	return handlers.ShowHelp(os.Stdout)
 }
 
 func cmdVersion(_ storage.TasksStorage, _ []string, _ string) error {
@@ -253,8 +1007,8 @@ func cmdVersion(_ storage.TasksStorage, _ []string, _ string) error {
 func getNamespace() string {
 	namespace, err := getNamespaceFromEnvOrFromFile()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: %s, using default namespace (%s)\n", err, DEFAULT_NAMESPACE)
-		return DEFAULT_NAMESPACE
+		fmt.Fprintf(os.Stderr, "Warning: %s, using default namespace (%s)\n", err, cfg.DefaultNamespace)
+		return cfg.DefaultNamespace
 	}
 
 	return namespace
@@ -270,7 +1024,7 @@ func getNamespaceFromEnvOrFromFile() (string, error) {
 	foundEnvFile := findFileUpTree(curdir, ENVFILE)
 
 	if foundEnvFile == "" {
-		return DEFAULT_NAMESPACE, nil
+		return cfg.DefaultNamespace, nil
 	}
 
 	envFileContent, err := os.ReadFile(foundEnvFile)
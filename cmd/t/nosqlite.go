@@ -0,0 +1,14 @@
+//go:build !tsqlite
+
+package main
+
+import (
+	storage "github.com/thek4n/t/internal/storage"
+)
+
+const DEFAULT_BACKEND = BACKEND_FS
+
+func initSqlTaskStorage(_ string, _ storage.SortOrder, _ map[string]storage.SortOrder) storage.TasksStorage {
+	die("Backend '%s' is not supported by this build, rebuild with --tags=tsqlite", BACKEND_SQLITE)
+	return nil
+}
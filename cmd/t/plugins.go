@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Unknown command NAME runs executable 't-NAME' from PATH, like git does
+const PLUGIN_PREFIX = "t-"
+
+// Returns path of plugin executable, empty if not found
+func findPlugin(name string) string {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, "-") {
+		return ""
+	}
+
+	pluginPath, err := exec.LookPath(PLUGIN_PREFIX + name)
+	if err != nil {
+		return ""
+	}
+	return pluginPath
+}
+
+// Runs plugin with namespace, data directory and backend in environment, returns its exit code
+func runPlugin(pluginPath string, args []string, namespace string) (int, error) {
+	baseDir, err := getBaseDir()
+	if err != nil {
+		return 1, err
+	}
+
+	cmd := exec.Command(pluginPath, args...)
+	cmd.Env = append(os.Environ(),
+		"t="+namespace, // nested calls of t use the same namespace
+		"T_NAMESPACE="+namespace,
+		"T_DIR="+baseDir,
+		"T_BACKEND="+getBackend(),
+	)
+
+	executable, err := os.Executable()
+	if err == nil {
+		cmd.Env = append(cmd.Env, "T_EXECUTABLE="+executable)
+	}
+
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	err = cmd.Run()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return 1, err
+	}
+	return 0, nil
+}
+
+// Returns names of plugins found in PATH
+func pluginNames() []string {
+	found := map[string]bool{}
+
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		matches, err := filepath.Glob(filepath.Join(dir, PLUGIN_PREFIX+"*"))
+		if err != nil {
+			continue
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil || info.IsDir() || info.Mode().Perm()&0111 == 0 {
+				continue
+			}
+			found[strings.TrimPrefix(filepath.Base(match), PLUGIN_PREFIX)] = true
+		}
+	}
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		if _, isCommand := COMMANDS[name]; !isCommand {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
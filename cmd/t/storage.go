@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	storage "github.com/thek4n/t/internal/storage"
+)
+
+const T_DATA_DIR = "t"
+const T_LEGACY_BASE_DIR = ".t"
+
+const BACKEND_FS = "fs"
+const BACKEND_SQLITE = "sqlite"
+
+// Opens storage of backend from config, encrypted storage asks passphrase only if interactive is set,
+// otherwise encrypted storage without key is nil, so completion works on locked tasks
+func initTaskStorage(interactive bool) storage.TasksStorage {
+	tBasePath, err := getBaseDir()
+	if err != nil {
+		die("%s", err.Error())
+	}
+
+	err = createDirectoryIfNotExists(tBasePath)
+	if err != nil {
+		die("%s", err.Error())
+	}
+
+	var s storage.TasksStorage
+	switch getBackend() {
+	case BACKEND_FS:
+		s = initFSTaskStorage(tBasePath, sortOrder, namespaceSorts)
+	case BACKEND_SQLITE:
+		s = initSqlTaskStorage(tBasePath, sortOrder, namespaceSorts)
+	default:
+		die("Unknown backend '%s', expected '%s' or '%s'", cfg.Backend, BACKEND_FS, BACKEND_SQLITE)
+	}
+
+	s, err = openEncrypted(s, tBasePath, interactive)
+	if err != nil && !interactive {
+		return nil
+	}
+	if err != nil {
+		die("%s", err)
+	}
+	return s
+}
+
+func getBackend() string {
+	if cfg.Backend != "" {
+		return cfg.Backend
+	}
+	return DEFAULT_BACKEND
+}
+
+// Data directory is taken from flag --dir, then variable T_DIR, then 'data_dir' from config,
+// then $XDG_DATA_HOME/t, default is ~/.local/share/t
+func getBaseDir() (string, error) {
+	if dataDirFlag != "" {
+		return dataDirFlag, nil
+	}
+
+	tDir := os.Getenv("T_DIR")
+	if tDir != "" {
+		return tDir, nil
+	}
+
+	if cfg.DataDir != "" {
+		return cfg.DataDir, nil
+	}
+
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	home := os.Getenv("HOME")
+
+	if dataHome == "" && home == "" {
+		return "", fmt.Errorf("Neither HOME nor XDG_DATA_HOME environment variable is set, use T_DIR or --dir")
+	}
+
+	if dataHome == "" {
+		dataHome = path.Join(home, ".local", "share")
+	}
+
+	tBasePath := path.Join(dataHome, T_DATA_DIR)
+
+	if home == "" {
+		return tBasePath, nil
+	}
+
+	return migrateLegacyBaseDir(path.Join(home, T_LEGACY_BASE_DIR), tBasePath), nil
+}
+
+// Moves data from ~/.t used by previous versions if new data directory not exists yet,
+// returns directory to use
+func migrateLegacyBaseDir(legacyPath string, tBasePath string) string {
+	if exists(tBasePath) || !exists(legacyPath) {
+		return tBasePath
+	}
+
+	err := os.MkdirAll(path.Dir(tBasePath), 0755)
+	if err == nil {
+		err = os.Rename(legacyPath, tBasePath)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: can't move task data from %s to %s: %s\n", legacyPath, tBasePath, err)
+		return legacyPath
+	}
+
+	fmt.Fprintf(os.Stderr, "Notice: task data moved from %s to %s\n", legacyPath, tBasePath)
+	return tBasePath
+}
+
+// Hooks are kept next to config file, not in ~/.t/hooks, because data directory of fs backend
+// would list directory of hooks as namespace. Executable hooks from ~/.t/hooks are moved
+// to new directory if it not exists yet, returns directory to use
+func migrateLegacyHooksDir(legacyPath string, hooksDir string) string {
+	if exists(hooksDir) || !hasExecutables(legacyPath) {
+		return hooksDir
+	}
+
+	err := os.MkdirAll(path.Dir(hooksDir), 0755)
+	if err == nil {
+		err = os.Rename(legacyPath, hooksDir)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: can't move hooks from %s to %s: %s\n", legacyPath, hooksDir, err)
+		return legacyPath
+	}
+
+	fmt.Fprintf(os.Stderr, "Notice: hooks moved from %s to %s\n", legacyPath, hooksDir)
+	return hooksDir
+}
+
+// Namespace 'hooks' of legacy data directory contains only not executable files of tasks
+func hasExecutables(dir string) bool {
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+
+	for _, de := range dirEntries {
+		info, err := de.Info()
+		if err == nil && info.Mode().IsRegular() && info.Mode().Perm()&0111 != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
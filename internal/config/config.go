@@ -0,0 +1,290 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const DEFAULT_NAMESPACE = "def"
+const DEFAULT_LINES_COUNT_LIMIT = 70
+const CONFIG_FILE = "config.toml"
+
+// Config read from file in simple toml format:
+//
+//	default_namespace = "work"
+//	[alias]
+//	ls = "show"
+type Config struct {
+	DefaultNamespace string
+	DataDir          string
+	Backend          string
+	Editor           string
+	Sort             string
+	Color            string
+	HooksDir         string
+	LinesCountLimit  int
+	Recursive        bool
+	Colors           map[string]string
+	Aliases          map[string]string
+	Formats          map[string]string
+	Sorts            map[string]string
+}
+
+func Default() Config {
+	return Config{
+		DefaultNamespace: DEFAULT_NAMESPACE,
+		LinesCountLimit:  DEFAULT_LINES_COUNT_LIMIT,
+		Colors:           map[string]string{},
+		Aliases:          map[string]string{},
+		Formats:          map[string]string{},
+		Sorts:            map[string]string{},
+	}
+}
+
+// Returns path of config file, T_CONFIG environment variable overwrites default path
+func Path() (string, error) {
+	configPath := os.Getenv("T_CONFIG")
+	if configPath != "" {
+		return configPath, nil
+	}
+
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome != "" {
+		return path.Join(configHome, "t", CONFIG_FILE), nil
+	}
+
+	home := os.Getenv("HOME")
+	if home == "" {
+		return "", fmt.Errorf("HOME environment variable is invalid")
+	}
+
+	return path.Join(home, ".config", "t", CONFIG_FILE), nil
+}
+
+// Returns directory of hooks, default is 'hooks' next to config file,
+// not in data directory, where fs backend would list it as namespace
+func (cfg Config) Hooks() (string, error) {
+	if cfg.HooksDir != "" {
+		return cfg.HooksDir, nil
+	}
+
+	configPath, err := Path()
+	if err != nil {
+		return "", err
+	}
+
+	return path.Join(path.Dir(configPath), "hooks"), nil
+}
+
+// Loads config from file, missing file is not an error
+func Load() (Config, error) {
+	configPath, err := Path()
+	if err != nil {
+		return Default(), nil
+	}
+
+	file, err := os.Open(configPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return Default(), nil
+	}
+	if err != nil {
+		return Default(), err
+	}
+	defer file.Close()
+
+	cfg, err := Parse(file)
+	if err != nil {
+		return Default(), fmt.Errorf("Error parse config %s: %s", configPath, err)
+	}
+
+	return cfg, nil
+}
+
+func Parse(r io.Reader) (Config, error) {
+	cfg := Default()
+	section := ""
+
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' {
+			if line[len(line)-1] != ']' {
+				return cfg, fmt.Errorf("line %d: unclosed section", lineNumber)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		key, rawValue, found := strings.Cut(line, "=")
+		if !found {
+			return cfg, fmt.Errorf("line %d: expected 'key = value'", lineNumber)
+		}
+
+		key = strings.TrimSpace(key)
+		value, err := parseValue(strings.TrimSpace(rawValue))
+		if err != nil {
+			return cfg, fmt.Errorf("line %d: %s", lineNumber, err)
+		}
+
+		err = cfg.set(section, key, value)
+		if err != nil {
+			return cfg, fmt.Errorf("line %d: %s", lineNumber, err)
+		}
+	}
+
+	return cfg, scanner.Err()
+}
+
+func (cfg *Config) set(section string, key string, value string) error {
+	switch section {
+	case "colors":
+		cfg.Colors[key] = value
+		return nil
+
+	case "alias":
+		cfg.Aliases[key] = value
+		return nil
+
+	case "formats":
+		cfg.Formats[key] = value
+		return nil
+
+	case "sort":
+		cfg.Sorts[key] = value
+		return nil
+
+	case "":
+		return cfg.setOption(key, value)
+	}
+
+	return fmt.Errorf("unknown section '%s'", section)
+}
+
+func (cfg *Config) setOption(key string, value string) error {
+	switch key {
+	case "default_namespace":
+		cfg.DefaultNamespace = value
+	case "data_dir":
+		cfg.DataDir = expandHome(value)
+	case "backend":
+		cfg.Backend = value
+	case "editor":
+		cfg.Editor = value
+	case "sort":
+		cfg.Sort = value
+	case "color":
+		cfg.Color = value
+	case "hooks_dir":
+		cfg.HooksDir = expandHome(value)
+	case "recursive":
+		recursive, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("recursive must be true or false")
+		}
+		cfg.Recursive = recursive
+	case "lines_count_limit":
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("lines_count_limit must be a number")
+		}
+		cfg.LinesCountLimit = limit
+	default:
+		return fmt.Errorf("unknown option '%s'", key)
+	}
+
+	return nil
+}
+
+// Value is bare word, or string in double quotes with escapes, or string in single quotes as is
+func parseValue(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+
+	switch raw[0] {
+	case '"':
+		end := closingQuote(raw)
+		if end < 0 {
+			return "", fmt.Errorf("unclosed string")
+		}
+		return strconv.Unquote(raw[:end+1])
+
+	case '\'':
+		end := strings.IndexByte(raw[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unclosed string")
+		}
+		return raw[1 : end+1], nil
+	}
+
+	value, _, _ := strings.Cut(raw, "#")
+	return strings.TrimSpace(value), nil
+}
+
+func closingQuote(raw string) int {
+	for i := 1; i < len(raw); i++ {
+		switch raw[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+func expandHome(p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		return path.Join(os.Getenv("HOME"), p[1:])
+	}
+	return p
+}
+
+// Splits command line by spaces, respecting single and double quotes
+func SplitArgs(s string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+
+	for _, c := range s {
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(c)
+		case c == '\'' || c == '"':
+			quote = c
+			inArg = true
+		case c == ' ' || c == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(c)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unclosed quote in '%s'", s)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
@@ -0,0 +1,314 @@
+package formats
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const ICS_DATETIME_FORMAT = "20060102T150405Z"
+const ICS_FLOATING_DATETIME_FORMAT = "20060102T150405"
+const ICS_DATE_FORMAT = "20060102"
+const ICS_PRODID = "-//thek4n//t//EN"
+const ICS_LINE_LENGTH = 75
+
+// Task as VTODO component of RFC 5545 calendar, namespace is stored in CATEGORIES
+//
+//	BEGIN:VTODO
+//	UID:...
+//	SUMMARY:rotate logs
+//	DESCRIPTION:first line\nsecond line
+//	CATEGORIES:ops
+//	END:VTODO
+type IcsTask struct {
+	UID       string
+	Namespace string
+	Name      string
+	Content   []byte
+	Stamp     time.Time
+	Created   time.Time
+	Modified  time.Time
+	Done      bool
+	DoneAt    time.Time
+}
+
+// Stable UID of task, same task exported twice keeps its UID
+func IcsUID(namespace string, name string) string {
+	return fmt.Sprintf("%x@t", sha1.Sum([]byte(namespace+"/"+name)))
+}
+
+// Writes VCALENDAR with VTODO per task, lines are folded and terminated by CRLF
+func WriteIcs(w io.Writer, tasks []IcsTask) error {
+	bw := bufio.NewWriter(w)
+
+	writeIcsLine(bw, "BEGIN:VCALENDAR")
+	writeIcsLine(bw, "VERSION:2.0")
+	writeIcsLine(bw, "PRODID:"+ICS_PRODID)
+
+	for _, task := range tasks {
+		uid := task.UID
+		if uid == "" {
+			uid = IcsUID(task.Namespace, task.Name)
+		}
+
+		stamp := task.Stamp
+		if stamp.IsZero() {
+			stamp = time.Now()
+		}
+
+		writeIcsLine(bw, "BEGIN:VTODO")
+		writeIcsLine(bw, "UID:"+uid)
+		writeIcsLine(bw, "DTSTAMP:"+formatIcsTime(stamp))
+		if !task.Created.IsZero() {
+			writeIcsLine(bw, "CREATED:"+formatIcsTime(task.Created))
+		}
+		if !task.Modified.IsZero() {
+			writeIcsLine(bw, "LAST-MODIFIED:"+formatIcsTime(task.Modified))
+		}
+		writeIcsLine(bw, "SUMMARY:"+escapeIcsText(task.Name))
+
+		content := strings.TrimRight(string(task.Content), "\n")
+		if content != "" {
+			writeIcsLine(bw, "DESCRIPTION:"+escapeIcsText(content))
+		}
+		if task.Namespace != "" {
+			writeIcsLine(bw, "CATEGORIES:"+escapeIcsText(task.Namespace))
+		}
+
+		if task.Done {
+			writeIcsLine(bw, "STATUS:COMPLETED")
+			if !task.DoneAt.IsZero() {
+				writeIcsLine(bw, "COMPLETED:"+formatIcsTime(task.DoneAt))
+			}
+		} else {
+			writeIcsLine(bw, "STATUS:NEEDS-ACTION")
+		}
+		writeIcsLine(bw, "END:VTODO")
+	}
+
+	writeIcsLine(bw, "END:VCALENDAR")
+	return bw.Flush()
+}
+
+// Reads VTODO components, other components are skipped,
+// tasks without CATEGORIES are put in defaultNamespace
+func ReadIcs(r io.Reader, defaultNamespace string) ([]IcsTask, error) {
+	lines, err := unfoldIcsLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []IcsTask
+	var task *IcsTask
+	depth := 0
+
+	for i, line := range lines {
+		name, params, value, err := parseIcsLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", i+1, err)
+		}
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VTODO"):
+			task = &IcsTask{Namespace: defaultNamespace}
+			depth = 0
+			continue
+
+		case task == nil:
+			continue
+
+		case name == "BEGIN":
+			depth++ // nested component such as VALARM
+			continue
+
+		case name == "END" && depth > 0:
+			depth--
+			continue
+
+		case name == "END" && strings.EqualFold(value, "VTODO"):
+			if task.Name == "" {
+				return nil, fmt.Errorf("line %d: task without SUMMARY", i+1)
+			}
+			tasks = append(tasks, *task)
+			task = nil
+			continue
+
+		case depth > 0:
+			continue
+		}
+
+		err = task.set(name, params, value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", i+1, err)
+		}
+	}
+
+	if task != nil {
+		return nil, fmt.Errorf("unclosed VTODO")
+	}
+
+	return tasks, nil
+}
+
+func (task *IcsTask) set(name string, params map[string]string, value string) error {
+	var err error
+
+	switch name {
+	case "UID":
+		task.UID = value
+	case "SUMMARY":
+		task.Name = strings.Join(strings.Fields(unescapeIcsText(value)), " ")
+	case "DESCRIPTION":
+		content := unescapeIcsText(value)
+		if content != "" {
+			content += "\n"
+		}
+		task.Content = []byte(content)
+	case "CATEGORIES":
+		category, _, _ := strings.Cut(value, ",")
+		category = strings.TrimSpace(unescapeIcsText(category))
+		if category != "" {
+			task.Namespace = category
+		}
+	case "STATUS":
+		task.Done = strings.EqualFold(value, "COMPLETED")
+	case "DTSTAMP":
+		task.Stamp, err = parseIcsTime(value, params)
+	case "CREATED":
+		task.Created, err = parseIcsTime(value, params)
+	case "LAST-MODIFIED":
+		task.Modified, err = parseIcsTime(value, params)
+	case "COMPLETED":
+		task.Done = true
+		task.DoneAt, err = parseIcsTime(value, params)
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid %s: %s", name, err)
+	}
+	return nil
+}
+
+// Joins continuation lines, which start with space or tab
+func unfoldIcsLines(r io.Reader) ([]string, error) {
+	var lines []string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if len(lines) > 0 && line != "" && (line[0] == ' ' || line[0] == '\t') {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, scanner.Err()
+}
+
+// Parses 'NAME;PARAM=VALUE:VALUE', quoted parameter values may contain ':' and ';'
+func parseIcsLine(line string) (string, map[string]string, string, error) {
+	params := map[string]string{}
+	inQuotes := false
+	colon := -1
+
+	for i := 0; i < len(line) && colon < 0; i++ {
+		switch line[i] {
+		case '"':
+			inQuotes = !inQuotes
+		case ':':
+			if !inQuotes {
+				colon = i
+			}
+		}
+	}
+
+	if colon < 0 {
+		return "", nil, "", fmt.Errorf("expected 'NAME:VALUE'")
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+
+	return strings.ToUpper(parts[0]), params, line[colon+1:], nil
+}
+
+func writeIcsLine(w *bufio.Writer, line string) {
+	for len(line) > ICS_LINE_LENGTH {
+		cut := ICS_LINE_LENGTH
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+
+		w.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+	}
+	w.WriteString(line + "\r\n")
+}
+
+func escapeIcsText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+func unescapeIcsText(s string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String()
+}
+
+func formatIcsTime(t time.Time) string {
+	return t.UTC().Format(ICS_DATETIME_FORMAT)
+}
+
+// Accepts UTC and floating date-time, date-time with TZID and date
+func parseIcsTime(value string, params map[string]string) (time.Time, error) {
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(ICS_DATETIME_FORMAT, value)
+	}
+
+	location := time.Local
+	if tzid, found := params["TZID"]; found {
+		if tz, err := time.LoadLocation(tzid); err == nil {
+			location = tz
+		}
+	}
+
+	if params["VALUE"] == "DATE" || len(value) == len(ICS_DATE_FORMAT) {
+		return time.ParseInLocation(ICS_DATE_FORMAT, value, location)
+	}
+
+	return time.ParseInLocation(ICS_FLOATING_DATETIME_FORMAT, value, location)
+}
@@ -0,0 +1,87 @@
+package formats
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIcsRoundTrip(t *testing.T) {
+	created := time.Date(2026, 10, 1, 9, 30, 0, 0, time.UTC)
+	modified := time.Date(2026, 10, 19, 18, 0, 5, 0, time.UTC)
+
+	tasks := []IcsTask{
+		{
+			UID:       IcsUID("ops", "rotate logs"),
+			Namespace: "ops",
+			Name:      "rotate logs",
+			Content:   []byte("first; second, third\n\\ back slash\n" + strings.Repeat("длинная строка ", 20) + "\n"),
+			Stamp:     modified,
+			Created:   created,
+			Modified:  modified,
+		},
+		{
+			UID:       "external-uid",
+			Namespace: "home",
+			Name:      "call mom",
+			Stamp:     modified,
+			Done:      true,
+			DoneAt:    modified,
+		},
+	}
+
+	var buf bytes.Buffer
+	err := WriteIcs(&buf, tasks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		if len(line) > ICS_LINE_LENGTH+1 {
+			t.Errorf("line is not folded: %q", line)
+		}
+	}
+
+	parsed, err := ReadIcs(&buf, "def")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(parsed, tasks) {
+		t.Errorf("round trip of %+v, got %+v", tasks, parsed)
+	}
+}
+
+func TestReadIcs(t *testing.T) {
+	content := "BEGIN:VCALENDAR\n" +
+		"BEGIN:VEVENT\nSUMMARY:meeting\nEND:VEVENT\n" +
+		"BEGIN:VTODO\n" +
+		"SUMMARY:plan\n  sprint\n" +
+		"DTSTAMP;TZID=UTC:20261019T120000\n" +
+		"CREATED;VALUE=DATE:20261001\n" +
+		"BEGIN:VALARM\nDESCRIPTION:reminder\nEND:VALARM\n" +
+		"END:VTODO\n" +
+		"END:VCALENDAR\n"
+
+	tasks, err := ReadIcs(strings.NewReader(content), "work")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []IcsTask{{
+		Namespace: "work",
+		Name:      "plan sprint",
+		Stamp:     time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+		Created:   time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local),
+	}}
+	if !reflect.DeepEqual(tasks, expected) {
+		t.Errorf("expected %+v, got %+v", expected, tasks)
+	}
+
+	_, err = ReadIcs(strings.NewReader("BEGIN:VTODO\nDESCRIPTION:x\nEND:VTODO\n"), "def")
+	if err == nil {
+		t.Errorf("expected error for task without SUMMARY")
+	}
+}
@@ -0,0 +1,114 @@
+package formats
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Task in exchange format, Name is human readable name of task
+type Task struct {
+	Namespace string
+	Name      string
+	Content   []byte
+}
+
+// Writes tasks as markdown document with heading '# NAMESPACE' per namespace
+// and heading '## NAME' per task, body lines starting with '#' or '\' are escaped by '\'
+func WriteMarkdown(w io.Writer, tasks []Task) error {
+	bw := bufio.NewWriter(w)
+	currentNamespace := ""
+
+	for i, task := range tasks {
+		if i == 0 || task.Namespace != currentNamespace {
+			if i > 0 {
+				bw.WriteString("\n")
+			}
+			fmt.Fprintf(bw, "# %s\n", task.Namespace)
+			currentNamespace = task.Namespace
+		}
+
+		fmt.Fprintf(bw, "\n## %s\n", task.Name)
+
+		content := bytes.TrimRight(task.Content, "\n")
+		if len(content) == 0 {
+			continue
+		}
+
+		bw.WriteString("\n")
+		for _, line := range strings.Split(string(content), "\n") {
+			if strings.HasPrefix(line, "#") || strings.HasPrefix(line, `\`) {
+				line = `\` + line
+			}
+			bw.WriteString(line + "\n")
+		}
+	}
+
+	return bw.Flush()
+}
+
+// Reads tasks from markdown document written by WriteMarkdown,
+// tasks before first namespace heading belong to defaultNamespace
+func ReadMarkdown(r io.Reader, defaultNamespace string) ([]Task, error) {
+	var tasks []Task
+	var body []string
+	namespace := defaultNamespace
+	inTask := false
+
+	flush := func() {
+		if inTask {
+			tasks[len(tasks)-1].Content = markdownBody(body)
+		}
+		body = body[:0]
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, "## "):
+			flush()
+			name := strings.TrimSpace(line[3:])
+			if name == "" {
+				return nil, fmt.Errorf("Empty task name in heading '%s'", line)
+			}
+			tasks = append(tasks, Task{Namespace: namespace, Name: name})
+			inTask = true
+
+		case strings.HasPrefix(line, "# "):
+			flush()
+			namespace = strings.TrimSpace(line[2:])
+			if namespace == "" {
+				return nil, fmt.Errorf("Empty namespace in heading '%s'", line)
+			}
+			inTask = false
+
+		case strings.HasPrefix(line, `\`):
+			body = append(body, line[1:])
+
+		default:
+			body = append(body, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	flush()
+	return tasks, nil
+}
+
+// Body without blank lines around it, ended by line break if not empty
+func markdownBody(lines []string) []byte {
+	content := strings.Trim(strings.Join(lines, "\n"), "\n")
+	if strings.TrimSpace(content) == "" {
+		return []byte{}
+	}
+	return []byte(content + "\n")
+}
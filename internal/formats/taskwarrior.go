@@ -0,0 +1,121 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const TASKWARRIOR_TIME_FORMAT = "20060102T150405Z"
+
+// Statuses of tasks in Taskwarrior export
+const (
+	TASKWARRIOR_PENDING   = "pending"
+	TASKWARRIOR_WAITING   = "waiting"
+	TASKWARRIOR_COMPLETED = "completed"
+	TASKWARRIOR_DELETED   = "deleted"
+	TASKWARRIOR_RECURRING = "recurring"
+)
+
+// Task from output of 'task export', only fields known by t
+type TaskwarriorTask struct {
+	UUID        string                  `json:"uuid"`
+	Description string                  `json:"description"`
+	Project     string                  `json:"project"`
+	Status      string                  `json:"status"`
+	Priority    string                  `json:"priority"`
+	Entry       TaskwarriorTime         `json:"entry"`
+	Modified    TaskwarriorTime         `json:"modified"`
+	End         TaskwarriorTime         `json:"end"`
+	Annotations []TaskwarriorAnnotation `json:"annotations"`
+}
+
+type TaskwarriorAnnotation struct {
+	Entry       TaskwarriorTime `json:"entry"`
+	Description string          `json:"description"`
+}
+
+// Time in Taskwarrior format '20261019T120000Z'
+type TaskwarriorTime struct {
+	time.Time
+}
+
+func (t *TaskwarriorTime) UnmarshalJSON(data []byte) error {
+	var s string
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return err
+	}
+
+	if s == "" {
+		t.Time = time.Time{}
+		return nil
+	}
+
+	t.Time, err = time.Parse(TASKWARRIOR_TIME_FORMAT, s)
+	return err
+}
+
+// Reads JSON array of tasks or one task object per line, as older versions of Taskwarrior export
+func ReadTaskwarrior(r io.Reader) ([]TaskwarriorTask, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var tasks []TaskwarriorTask
+
+	if data[0] == '[' {
+		err = json.Unmarshal(data, &tasks)
+		if err != nil {
+			return nil, err
+		}
+		return tasks, nil
+	}
+
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSuffix(bytes.TrimSpace(line), []byte(","))
+		if len(line) == 0 {
+			continue
+		}
+
+		var task TaskwarriorTask
+		err = json.Unmarshal(line, &task)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", i+1, err)
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, nil
+}
+
+// Annotations are lines of task body
+func (task TaskwarriorTask) Content() []byte {
+	var b strings.Builder
+	for _, annotation := range task.Annotations {
+		b.WriteString(annotation.Description + "\n")
+	}
+	return []byte(b.String())
+}
+
+// Maps priorities H, M and L to A, B and C
+func (task TaskwarriorTask) TodoPriority() string {
+	switch task.Priority {
+	case "H":
+		return "A"
+	case "M":
+		return "B"
+	case "L":
+		return "C"
+	}
+	return ""
+}
@@ -0,0 +1,49 @@
+package formats
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadTaskwarrior(t *testing.T) {
+	array := `[
+{"id":1,"description":"rotate logs","entry":"20261001T093000Z","modified":"20261002T100000Z","project":"ops","priority":"H","status":"pending","uuid":"a","annotations":[{"entry":"20261002T100000Z","description":"check disk"},{"entry":"20261002T100001Z","description":"then cron"}]},
+{"id":0,"description":"call mom","end":"20261019T120000Z","entry":"20261001T093000Z","modified":"20261019T120000Z","status":"completed","uuid":"b","urgency":0}
+]`
+	lines := strings.ReplaceAll(strings.Trim(array, "[]\n"), "},\n{", "}\n{")
+
+	for _, input := range []string{array, lines} {
+		tasks, err := ReadTaskwarrior(strings.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(tasks) != 2 {
+			t.Fatalf("expected 2 tasks, got %d", len(tasks))
+		}
+
+		task := tasks[0]
+		if task.Project != "ops" || task.Description != "rotate logs" || task.TodoPriority() != "A" {
+			t.Errorf("unexpected task %+v", task)
+		}
+		if string(task.Content()) != "check disk\nthen cron\n" {
+			t.Errorf("unexpected content %q", task.Content())
+		}
+		if !task.Entry.Equal(time.Date(2026, 10, 1, 9, 30, 0, 0, time.UTC)) {
+			t.Errorf("unexpected entry %s", task.Entry)
+		}
+
+		done := tasks[1]
+		if done.Status != TASKWARRIOR_COMPLETED || !done.End.Equal(time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected completed task %+v", done)
+		}
+	}
+}
+
+func TestReadTaskwarriorInvalidTime(t *testing.T) {
+	_, err := ReadTaskwarrior(strings.NewReader(`[{"description":"x","entry":"yesterday"}]`))
+	if err == nil {
+		t.Errorf("expected error for invalid time")
+	}
+}
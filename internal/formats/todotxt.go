@@ -0,0 +1,148 @@
+package formats
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const TODOTXT_DATE_FORMAT = "2006-01-02"
+
+// Line of todo.txt file, last '+project' of line is namespace of task
+//
+//	x 2026-10-19 2026-10-01 review PR @work +backend pri:A
+//	(A) 2026-10-01 rotate logs +ops
+type TodoTxtTask struct {
+	Done      bool
+	Priority  string
+	DoneAt    time.Time
+	Created   time.Time
+	Name      string
+	Namespace string
+}
+
+func ReadTodoTxt(r io.Reader, defaultNamespace string) ([]TodoTxtTask, error) {
+	var tasks []TodoTxtTask
+
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		task, err := ParseTodoTxtLine(line, defaultNamespace)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", lineNumber, err)
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, scanner.Err()
+}
+
+func WriteTodoTxt(w io.Writer, tasks []TodoTxtTask) error {
+	bw := bufio.NewWriter(w)
+	for _, task := range tasks {
+		bw.WriteString(FormatTodoTxtLine(task) + "\n")
+	}
+	return bw.Flush()
+}
+
+func ParseTodoTxtLine(line string, defaultNamespace string) (TodoTxtTask, error) {
+	task := TodoTxtTask{Namespace: defaultNamespace}
+	words := strings.Fields(line)
+
+	if len(words) > 0 && words[0] == "x" {
+		task.Done = true
+		words = words[1:]
+
+		if len(words) > 0 {
+			if doneAt, err := parseTodoTxtDate(words[0]); err == nil {
+				task.DoneAt = doneAt
+				words = words[1:]
+			}
+		}
+	}
+
+	if len(words) > 0 && isTodoTxtPriority(words[0]) {
+		task.Priority = words[0][1:2]
+		words = words[1:]
+	}
+
+	if len(words) > 0 {
+		if created, err := parseTodoTxtDate(words[0]); err == nil {
+			task.Created = created
+			words = words[1:]
+		}
+	}
+
+	namespaceIndex := -1
+	for i, word := range words {
+		if len(word) > 1 && word[0] == '+' {
+			namespaceIndex = i
+		}
+		if task.Done && strings.HasPrefix(word, "pri:") && len(word) == 5 && isPriorityLetter(word[4]) {
+			task.Priority = word[4:]
+			words[i] = ""
+		}
+	}
+
+	if namespaceIndex >= 0 {
+		task.Namespace = words[namespaceIndex][1:]
+		words[namespaceIndex] = ""
+	}
+
+	task.Name = strings.Join(strings.Fields(strings.Join(words, " ")), " ")
+	if task.Name == "" {
+		return task, fmt.Errorf("empty task description")
+	}
+
+	return task, nil
+}
+
+// Completed tasks keep priority in tag 'pri:X' as recommended by todo.txt format
+func FormatTodoTxtLine(task TodoTxtTask) string {
+	var parts []string
+
+	if task.Done {
+		parts = append(parts, "x")
+		if !task.DoneAt.IsZero() {
+			parts = append(parts, task.DoneAt.Format(TODOTXT_DATE_FORMAT))
+		}
+	}
+
+	if !task.Done && task.Priority != "" {
+		parts = append(parts, "("+task.Priority+")")
+	}
+
+	if !task.Created.IsZero() {
+		parts = append(parts, task.Created.Format(TODOTXT_DATE_FORMAT))
+	}
+
+	parts = append(parts, task.Name)
+
+	if task.Namespace != "" {
+		parts = append(parts, "+"+task.Namespace)
+	}
+
+	if task.Done && task.Priority != "" {
+		parts = append(parts, "pri:"+task.Priority)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func parseTodoTxtDate(s string) (time.Time, error) {
+	return time.ParseInLocation(TODOTXT_DATE_FORMAT, s, time.Local)
+}
+
+func isTodoTxtPriority(word string) bool {
+	return len(word) == 3 && word[0] == '(' && isPriorityLetter(word[1]) && word[2] == ')'
+}
+
+func isPriorityLetter(c byte) bool {
+	return c >= 'A' && c <= 'Z'
+}
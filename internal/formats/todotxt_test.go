@@ -0,0 +1,96 @@
+package formats
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.ParseInLocation(TODOTXT_DATE_FORMAT, s, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestTodoTxtLineRoundTrip(t *testing.T) {
+	lines := []string{
+		"rotate logs +def",
+		"(A) review PR @work +backend",
+		"(B) 2026-10-01 call mom @phone +home",
+		"x 2026-10-19 2026-10-01 deploy +ops",
+		"x 2026-10-19 deploy +ops pri:C",
+		"fix +1 bug +work",
+	}
+
+	for _, line := range lines {
+		task, err := ParseTodoTxtLine(line, "def")
+		if err != nil {
+			t.Fatalf("ParseTodoTxtLine(%q): %s", line, err)
+		}
+
+		formatted := FormatTodoTxtLine(task)
+		if formatted != line {
+			t.Errorf("round trip of %q, got %q", line, formatted)
+		}
+	}
+}
+
+func TestTodoTxtTaskRoundTrip(t *testing.T) {
+	tasks := []TodoTxtTask{
+		{Name: "buy bread", Namespace: "def"},
+		{Name: "review PR @work", Namespace: "backend", Priority: "A", Created: date("2026-10-01")},
+		{Name: "deploy", Namespace: "ops", Done: true, DoneAt: date("2026-10-19"), Created: date("2026-10-01")},
+		{Name: "write report", Namespace: "work", Done: true, DoneAt: date("2026-10-19"), Priority: "B"},
+	}
+
+	for _, task := range tasks {
+		parsed, err := ParseTodoTxtLine(FormatTodoTxtLine(task), "other")
+		if err != nil {
+			t.Fatalf("ParseTodoTxtLine(FormatTodoTxtLine(%+v)): %s", task, err)
+		}
+
+		if !reflect.DeepEqual(parsed, task) {
+			t.Errorf("round trip of %+v, got %+v", task, parsed)
+		}
+	}
+}
+
+func TestTodoTxtFileRoundTrip(t *testing.T) {
+	content := "(A) 2026-10-01 rotate logs +ops\nx 2026-10-19 call +def\n"
+
+	tasks, err := ReadTodoTxt(strings.NewReader(content+"\n"), "def")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	err = WriteTodoTxt(&buf, tasks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != content {
+		t.Errorf("expected %q, got %q", content, buf.String())
+	}
+}
+
+func TestParseTodoTxtLine(t *testing.T) {
+	task, err := ParseTodoTxtLine("(C) 2026-10-01 plan sprint @office", "work")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := TodoTxtTask{Priority: "C", Created: date("2026-10-01"), Name: "plan sprint @office", Namespace: "work"}
+	if !reflect.DeepEqual(task, expected) {
+		t.Errorf("expected %+v, got %+v", expected, task)
+	}
+
+	_, err = ParseTodoTxtLine("(A) +ops", "def")
+	if err == nil {
+		t.Errorf("expected error for task without description")
+	}
+}
@@ -0,0 +1,530 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/thek4n/t/internal/formats"
+	storage "github.com/thek4n/t/internal/storage"
+)
+
+// Counts of imported tasks and tasks skipped by importer
+type ImportResult struct {
+	Imported int
+	Skipped  int
+}
+
+func ShowImportResult(w io.Writer, result ImportResult) {
+	fmt.Fprintf(w, "Imported %d tasks", result.Imported)
+	if result.Skipped > 0 {
+		fmt.Fprintf(w, ", skipped %d", result.Skipped)
+	}
+	fmt.Fprintln(w)
+}
+
+func ExportMarkdown(w io.Writer, namespaces []string, s storage.TasksStorage) error {
+	tasks, err := collectTasks(namespaces, s)
+	if err != nil {
+		return err
+	}
+
+	return formats.WriteMarkdown(w, tasks)
+}
+
+// Imports tasks from markdown, tasks with existing names are overwritten
+func ImportMarkdown(r io.Reader, namespace string, s storage.TasksStorage) (ImportResult, error) {
+	tasks, err := formats.ReadMarkdown(r, namespace)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	for _, task := range tasks {
+		err = validateImportNamespace(task.Namespace)
+		if err != nil {
+			return ImportResult{}, err
+		}
+	}
+
+	for _, task := range tasks {
+		err = importTask(task, s)
+		if err != nil {
+			return ImportResult{}, fmt.Errorf("Error importing task '%s': %w", task.Name, err)
+		}
+	}
+
+	return ImportResult{Imported: len(tasks)}, nil
+}
+
+// Exports active and completed tasks with creation date, active tasks with priority
+func ExportTodoTxt(w io.Writer, namespaces []string, s storage.TasksStorage) error {
+	var tasks []formats.TodoTxtTask
+
+	for _, namespace := range namespaces {
+		names, err := s.GetSorted(namespace)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			info, err := s.GetInfo(namespace, name)
+			if err != nil {
+				return err
+			}
+
+			tasks = append(tasks, formats.TodoTxtTask{
+				Name:      strings.ReplaceAll(name, PATH_SEPARATOR_REPLACER, "/"),
+				Namespace: namespace,
+				Priority:  info.Priority,
+				Created:   info.Created,
+			})
+		}
+
+		doneTasks, err := s.GetDone(namespace, time.Time{})
+		if err != nil {
+			return err
+		}
+
+		for _, doneTask := range doneTasks {
+			tasks = append(tasks, formats.TodoTxtTask{
+				Done:      true,
+				DoneAt:    doneTask.DoneAt,
+				Name:      strings.ReplaceAll(doneTask.Name, PATH_SEPARATOR_REPLACER, "/"),
+				Namespace: namespace,
+				Created:   doneTask.Created,
+			})
+		}
+	}
+
+	return formats.WriteTodoTxt(w, tasks)
+}
+
+// Imports todo.txt tasks, '+project' is namespace, completed tasks go to done log.
+// Completed task is skipped if it is in done log already or pending task has its name
+func ImportTodoTxt(r io.Reader, namespace string, s storage.TasksStorage) (ImportResult, error) {
+	tasks, err := formats.ReadTodoTxt(r, namespace)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	for _, task := range tasks {
+		err = validateImportNamespace(task.Namespace)
+		if err != nil {
+			return ImportResult{}, err
+		}
+	}
+
+	result := ImportResult{}
+	for _, task := range tasks {
+		imported, err := importTodoTxtTask(task, s)
+		if err != nil {
+			return ImportResult{}, fmt.Errorf("Error importing task '%s': %w", task.Name, err)
+		}
+
+		if imported {
+			result.Imported++
+		} else {
+			result.Skipped++
+		}
+	}
+
+	return result, nil
+}
+
+func importTodoTxtTask(task formats.TodoTxtTask, s storage.TasksStorage) (bool, error) {
+	if task.Done {
+		doneTask := formats.Task{Namespace: task.Namespace, Name: task.Name}
+		return importDoneTask(doneTask, storage.TaskInfo{Created: task.Created}, task.DoneAt, s)
+	}
+
+	exists, err := taskExists(task.Namespace, task.Name, s)
+	if err != nil {
+		return false, err
+	}
+
+	if !exists {
+		err = s.Add(task.Namespace, task.Name)
+		if err != nil {
+			return false, err
+		}
+
+		err = s.SetTimes(task.Namespace, task.Name, storage.TaskInfo{Created: task.Created})
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, s.SetPriority(task.Namespace, task.Name, task.Priority)
+}
+
+// Adds task directly to done log, returns false if task is in done log already
+// or pending task has the same name, so repeated import doesn't complete tasks of user
+func importDoneTask(task formats.Task, info storage.TaskInfo, doneAt time.Time, s storage.TasksStorage) (bool, error) {
+	pending, err := taskExists(task.Namespace, task.Name, s)
+	if err != nil || pending {
+		return false, err
+	}
+
+	done, err := doneExists(task.Namespace, task.Name, doneAt, s)
+	if err != nil || done {
+		return false, err
+	}
+
+	if doneAt.IsZero() {
+		doneAt = time.Now()
+	}
+
+	err = importTask(task, s)
+	if err != nil {
+		return false, err
+	}
+
+	err = s.SetTimes(task.Namespace, task.Name, info)
+	if err != nil {
+		return false, err
+	}
+
+	return true, s.CompleteByName(task.Namespace, task.Name, doneAt)
+}
+
+// Compares days of completion, because todo.txt keeps only date,
+// task done at unknown time matches any done task with its name
+func doneExists(namespace string, name string, doneAt time.Time, s storage.TasksStorage) (bool, error) {
+	doneTasks, err := s.GetDone(namespace, time.Time{})
+	if err != nil {
+		return false, err
+	}
+
+	for _, task := range doneTasks {
+		if strings.ReplaceAll(task.Name, PATH_SEPARATOR_REPLACER, "/") != name {
+			continue
+		}
+
+		if doneAt.IsZero() || task.DoneAt.Local().Format("2006-01-02") == doneAt.Local().Format("2006-01-02") {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Exports tasks as VTODO components with timestamps from storage
+func ExportIcs(w io.Writer, namespaces []string, s storage.TasksStorage) error {
+	var tasks []formats.IcsTask
+
+	for _, namespace := range namespaces {
+		names, err := s.GetSorted(namespace)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			content, err := s.GetContentByName(namespace, name)
+			if err != nil {
+				return err
+			}
+
+			info, err := s.GetInfo(namespace, name)
+			if err != nil {
+				return err
+			}
+
+			tasks = append(tasks, formats.IcsTask{
+				Namespace: namespace,
+				Name:      strings.ReplaceAll(name, PATH_SEPARATOR_REPLACER, "/"),
+				Content:   content,
+				Stamp:     info.Updated,
+				Created:   info.Created,
+				Modified:  info.Updated,
+			})
+		}
+	}
+
+	return formats.WriteIcs(w, tasks)
+}
+
+// Imports VTODO components, CATEGORIES is namespace, completed tasks go to done log.
+// Completed task is skipped if it is in done log already or pending task has its name
+func ImportIcs(r io.Reader, namespace string, s storage.TasksStorage) (ImportResult, error) {
+	tasks, err := formats.ReadIcs(r, namespace)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	for _, task := range tasks {
+		err = validateImportNamespace(task.Namespace)
+		if err != nil {
+			return ImportResult{}, err
+		}
+	}
+
+	result := ImportResult{}
+	for _, task := range tasks {
+		imported, err := importIcsTask(task, s)
+		if err != nil {
+			return ImportResult{}, fmt.Errorf("Error importing task '%s': %w", task.Name, err)
+		}
+
+		if imported {
+			result.Imported++
+		} else {
+			result.Skipped++
+		}
+	}
+
+	return result, nil
+}
+
+func importIcsTask(task formats.IcsTask, s storage.TasksStorage) (bool, error) {
+	modified := task.Modified
+	if modified.IsZero() {
+		modified = task.Stamp
+	}
+	info := storage.TaskInfo{Created: task.Created, Updated: modified}
+
+	if task.Done {
+		doneTask := formats.Task{Namespace: task.Namespace, Name: task.Name, Content: task.Content}
+		return importDoneTask(doneTask, info, task.DoneAt, s)
+	}
+
+	exists, err := taskExists(task.Namespace, task.Name, s)
+	if err != nil {
+		return false, err
+	}
+
+	if !exists {
+		err = s.Add(task.Namespace, task.Name)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if !exists || task.Content != nil {
+		err = s.WriteByName(task.Namespace, task.Name, bytes.NewReader(task.Content))
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, s.SetTimes(task.Namespace, task.Name, info)
+}
+
+// Imports Taskwarrior export, project is namespace, annotations are body,
+// completed tasks go to done log, deleted and recurring templates are skipped.
+// Completed task is skipped if it is in done log already or pending task has its name
+func ImportTaskwarrior(r io.Reader, namespace string, s storage.TasksStorage) (ImportResult, error) {
+	tasks, err := formats.ReadTaskwarrior(r)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	for _, task := range tasks {
+		if task.Project != "" {
+			err = validateImportNamespace(task.Project)
+			if err != nil {
+				return ImportResult{}, err
+			}
+		}
+	}
+
+	result := ImportResult{}
+	for _, task := range tasks {
+		if task.Status == formats.TASKWARRIOR_DELETED || task.Status == formats.TASKWARRIOR_RECURRING {
+			result.Skipped++
+			continue
+		}
+
+		imported, err := importTaskwarriorTask(task, namespace, s)
+		if err != nil {
+			return ImportResult{}, fmt.Errorf("Error importing task '%s': %w", task.Description, err)
+		}
+
+		if imported {
+			result.Imported++
+		} else {
+			result.Skipped++
+		}
+	}
+
+	return result, nil
+}
+
+func importTaskwarriorTask(task formats.TaskwarriorTask, namespace string, s storage.TasksStorage) (bool, error) {
+	name := strings.Join(strings.Fields(task.Description), " ")
+	if name == "" {
+		return false, fmt.Errorf("Empty description of task %s", task.UUID)
+	}
+
+	if task.Project != "" {
+		namespace = task.Project
+	}
+
+	exchangeTask := formats.Task{Namespace: namespace, Name: name, Content: task.Content()}
+	info := storage.TaskInfo{Created: task.Entry.Time, Updated: task.Modified.Time}
+
+	if task.Status == formats.TASKWARRIOR_COMPLETED {
+		return importDoneTask(exchangeTask, info, task.End.Time, s)
+	}
+
+	err := importTask(exchangeTask, s)
+	if err != nil {
+		return false, err
+	}
+
+	err = s.SetTimes(namespace, name, info)
+	if err != nil {
+		return false, err
+	}
+
+	return true, s.SetPriority(namespace, name, task.TodoPriority())
+}
+
+const CSV_TIME_FORMAT = "2006-01-02 15:04:05"
+
+var CSV_HEADER = []string{"namespace", "index", "name", "lines", "created", "updated", "read", "deleted"}
+
+// Exports tasks as csv table, deleted tasks without index are included
+// if includeDeleted and backend keeps deleted tasks
+func ExportCsv(w io.Writer, namespaces []string, includeDeleted bool, s storage.TasksStorage) error {
+	trash, keepsDeleted := storage.AsTrash(s)
+	if includeDeleted && !keepsDeleted {
+		return fmt.Errorf("Deleted tasks are kept only by sqlite backend")
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write(CSV_HEADER)
+
+	for _, namespace := range namespaces {
+		tasks, err := ListTasks(namespace, s)
+		if err != nil {
+			return err
+		}
+
+		for i, tv := range tasks {
+			cw.Write([]string{
+				namespace,
+				fmt.Sprint(i + 1),
+				tv.FormattedName,
+				fmt.Sprint(tv.LinesCount),
+				formatCsvTime(tv.Created),
+				formatCsvTime(tv.Updated),
+				formatCsvTime(tv.Read),
+				"",
+			})
+		}
+
+		if !includeDeleted {
+			continue
+		}
+
+		deletedTasks, err := trash.GetDeleted(namespace)
+		if err != nil {
+			return err
+		}
+
+		for _, task := range deletedTasks {
+			cw.Write([]string{
+				namespace,
+				"",
+				strings.ReplaceAll(task.Name, PATH_SEPARATOR_REPLACER, "/"),
+				fmt.Sprint(task.LinesCount),
+				formatCsvTime(task.Info.Created),
+				formatCsvTime(task.Info.Updated),
+				formatCsvTime(task.Info.Read),
+				formatCsvTime(task.Deleted),
+			})
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatCsvTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format(CSV_TIME_FORMAT)
+}
+
+func collectTasks(namespaces []string, s storage.TasksStorage) ([]formats.Task, error) {
+	result := []formats.Task{}
+
+	for _, namespace := range namespaces {
+		tasks, err := s.GetSorted(namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, task := range tasks {
+			content, err := s.GetContentByName(namespace, task)
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, formats.Task{
+				Namespace: namespace,
+				Name:      strings.ReplaceAll(task, PATH_SEPARATOR_REPLACER, "/"),
+				Content:   content,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+func importTask(task formats.Task, s storage.TasksStorage) error {
+	exists, err := taskExists(task.Namespace, task.Name, s)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		err = s.Add(task.Namespace, task.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.WriteByName(task.Namespace, task.Name, bytes.NewReader(task.Content))
+}
+
+// Namespace from imported file is path of fs backend, so import is rejected before any task is written
+func validateImportNamespace(namespace string) error {
+	err := ValidateNamespace(namespace)
+	if err != nil {
+		return fmt.Errorf("Error importing: %w", err)
+	}
+	return nil
+}
+
+func taskExists(namespace string, name string, s storage.TasksStorage) (bool, error) {
+	namespaces, err := s.GetNamespaces()
+	if err != nil {
+		return false, err
+	}
+
+	found := false
+	for _, ns := range namespaces {
+		found = found || ns == namespace
+	}
+	if !found {
+		return false, nil
+	}
+
+	tasks, err := s.GetSorted(namespace)
+	if err != nil {
+		return false, err
+	}
+
+	for _, task := range tasks {
+		if strings.ReplaceAll(task, PATH_SEPARATOR_REPLACER, "/") == name {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
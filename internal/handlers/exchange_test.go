@@ -0,0 +1,240 @@
+package handlers_test
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	handlers "github.com/thek4n/t/internal/handlers"
+	storage "github.com/thek4n/t/internal/storage"
+)
+
+func newFSStorage(t *testing.T) (*storage.FSTasksStorage, string) {
+	root := t.TempDir()
+	err := os.Mkdir(path.Join(root, "data"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &storage.FSTasksStorage{TBaseDir: path.Join(root, "data"), Sort: storage.SORT_UPDATED}, root
+}
+
+func assertNotEscaped(t *testing.T, root string) {
+	t.Helper()
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != "data" {
+			t.Errorf("import created '%s' outside data directory", entry.Name())
+		}
+	}
+}
+
+func TestImportMarkdownRejectsInvalidNamespace(t *testing.T) {
+	s, root := newFSStorage(t)
+
+	input := "# def\n\n## first\n\n# ../escaped\n\n## pwned\n"
+	_, err := handlers.ImportMarkdown(strings.NewReader(input), "def", s)
+	if err == nil {
+		t.Fatal("expected error on namespace '../escaped'")
+	}
+
+	assertNotEscaped(t, root)
+
+	assertNoNamespaces(t, s)
+}
+
+func assertNoNamespaces(t *testing.T, s storage.TasksStorage) {
+	t.Helper()
+
+	namespaces, err := s.GetNamespaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(namespaces) != 0 {
+		t.Errorf("import expected to be rejected as whole, got namespaces %v", namespaces)
+	}
+}
+
+func TestImportTodoTxtRejectsInvalidNamespace(t *testing.T) {
+	s, root := newFSStorage(t)
+
+	_, err := handlers.ImportTodoTxt(strings.NewReader("first +def\npwned +../escaped\n"), "def", s)
+	if err == nil {
+		t.Fatal("expected error on namespace '../escaped'")
+	}
+
+	assertNotEscaped(t, root)
+	assertNoNamespaces(t, s)
+}
+
+func TestImportTodoTxtDoneTwice(t *testing.T) {
+	s, _ := newFSStorage(t)
+
+	input := "x 2026-10-19 deploy +ops\n"
+	for i := 0; i < 2; i++ {
+		_, err := handlers.ImportTodoTxt(strings.NewReader(input), "def", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	done, err := s.GetDone("ops", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 1 {
+		t.Errorf("expected 1 done task after repeated import, got %+v", done)
+	}
+
+	err = s.Add("ops", "deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := handlers.ImportTodoTxt(strings.NewReader("x 2026-10-20 deploy +ops\n"), "def", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported != 0 || result.Skipped != 1 {
+		t.Errorf("done task with name of pending task expected to be skipped, got %+v", result)
+	}
+
+	count, err := s.Count("ops")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("pending task expected to stay, got %d tasks", count)
+	}
+}
+
+func TestExportTodoTxtKeepsCreatedOfDoneTask(t *testing.T) {
+	s, _ := newFSStorage(t)
+
+	input := "x 2026-10-19 2026-10-01 deploy +ops\n"
+	_, err := handlers.ImportTodoTxt(strings.NewReader(input), "def", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var output strings.Builder
+	err = handlers.ExportTodoTxt(&output, []string{"ops"}, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.String() != input {
+		t.Errorf("expected exported %q, got %q", input, output.String())
+	}
+}
+
+func TestImportIcsRejectsInvalidNamespace(t *testing.T) {
+	s, root := newFSStorage(t)
+
+	input := "BEGIN:VCALENDAR\n" +
+		"BEGIN:VTODO\nSUMMARY:first\nEND:VTODO\n" +
+		"BEGIN:VTODO\nSUMMARY:pwned\nCATEGORIES:../escaped\nEND:VTODO\n" +
+		"END:VCALENDAR\n"
+	_, err := handlers.ImportIcs(strings.NewReader(input), "def", s)
+	if err == nil {
+		t.Fatal("expected error on namespace '../escaped'")
+	}
+
+	assertNotEscaped(t, root)
+	assertNoNamespaces(t, s)
+}
+
+func TestImportIcsDoneTwice(t *testing.T) {
+	s, _ := newFSStorage(t)
+
+	input := "BEGIN:VCALENDAR\n" +
+		"BEGIN:VTODO\nSUMMARY:deploy\nCATEGORIES:ops\nSTATUS:COMPLETED\nCOMPLETED:20261019T120000Z\nEND:VTODO\n" +
+		"END:VCALENDAR\n"
+	for i := 0; i < 2; i++ {
+		_, err := handlers.ImportIcs(strings.NewReader(input), "def", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	done, err := s.GetDone("ops", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 1 {
+		t.Errorf("expected 1 done task after repeated import, got %+v", done)
+	}
+
+	err = s.Add("ops", "deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := handlers.ImportIcs(strings.NewReader(strings.ReplaceAll(input, "20261019", "20261020")), "def", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported != 0 || result.Skipped != 1 {
+		t.Errorf("done task with name of pending task expected to be skipped, got %+v", result)
+	}
+
+	count, err := s.Count("ops")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("pending task expected to stay, got %d tasks", count)
+	}
+}
+
+func TestImportTaskwarriorRejectsInvalidNamespace(t *testing.T) {
+	s, root := newFSStorage(t)
+
+	input := `[{"description":"first","status":"pending","uuid":"a"},` +
+		`{"description":"pwned","project":"../escaped","status":"pending","uuid":"b"}]`
+	_, err := handlers.ImportTaskwarrior(strings.NewReader(input), "def", s)
+	if err == nil {
+		t.Fatal("expected error on project '../escaped'")
+	}
+
+	assertNotEscaped(t, root)
+	assertNoNamespaces(t, s)
+}
+
+func TestImportTaskwarriorKeepsPendingTask(t *testing.T) {
+	s, _ := newFSStorage(t)
+
+	err := s.Add("ops", "rotate logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := `[{"description":"rotate logs","project":"ops","end":"20261019T120000Z","status":"completed","uuid":"a"}]`
+	result, err := handlers.ImportTaskwarrior(strings.NewReader(input), "def", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported != 0 || result.Skipped != 1 {
+		t.Errorf("completed task with name of pending task expected to be skipped, got %+v", result)
+	}
+
+	count, err := s.Count("ops")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("pending task expected to stay, got %d tasks", count)
+	}
+
+	done, err := s.GetDone("ops", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 0 {
+		t.Errorf("expected empty done log, got %+v", done)
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"io"
+	"strings"
+	"text/template"
+
+	storage "github.com/thek4n/t/internal/storage"
+)
+
+// Shows tasks of namespaces rendered by text/template format, one task per line,
+// empty order is default order of namespace
+func ShowTasksFormatted(w io.Writer, namespaces []string, format string, order storage.SortOrder, s storage.TasksStorage) error {
+	tmpl, err := parseFormat(format)
+	if err != nil {
+		return err
+	}
+
+	for _, namespace := range namespaces {
+		tasks, err := ListTasksSorted(namespace, order, s)
+		if err != nil {
+			return err
+		}
+
+		for _, tv := range tasks {
+			err = tmpl.Execute(w, tv)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func ShowAllTasksFormatted(w io.Writer, format string, s storage.TasksStorage) error {
+	namespaces, err := s.GetNamespaces()
+	if err != nil {
+		return err
+	}
+
+	return ShowTasksFormatted(w, namespaces, format, storage.SortOrder{}, s)
+}
+
+// Format from command line may contain escaped tabs and newlines, line break added if missing
+func parseFormat(format string) (*template.Template, error) {
+	format = strings.NewReplacer(`\t`, "\t", `\n`, "\n", `\\`, `\`).Replace(format)
+	if !strings.HasSuffix(format, "\n") {
+		format += "\n"
+	}
+
+	return template.New("format").Parse(format)
+}
@@ -1,11 +1,18 @@
 package handlers
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/thek4n/t/internal/config"
+	"github.com/thek4n/t/internal/recurrence"
 	storage "github.com/thek4n/t/internal/storage"
 )
 
@@ -16,18 +23,56 @@ USAGE
 	t                            - Show tasks in format '[INDEX] TASK NAME (LINES)'
 	t get (TASK)                 - Get task content
 	t show                       - Show tasks in format '[INDEX] TASK NAME (LINES)'
+	t show --format (FORMAT)     - Show tasks in text/template FORMAT or named format from config
+	t show -r                    - Show tasks of namespace and its child namespaces
+	t show --sort (SORT)         - Show tasks sorted by name, created, updated, lines or priority,
+	                               --reverse inverts order, indexes stay from default order,
+	                               fs backend keeps only time of modification, so created is updated
+	t show --json                - Show tasks as json, also 't all --json', 't ns --json', 't log --json'
 	t (INDEX)                    - Show task content
 	t add (X X X)                - Add task with name X X X
-	t edit (INDEX)               - Edit task with INDEX by \$EDITOR
-	t done (INDEX) [INDEX] ...   - Delete tasks with INDEXes
-	t namespaces                 - Show namespaces
+	t edit (INDEX)               - Edit task with INDEX by editor from config or \$EDITOR
+	t done (INDEX) [INDEX] ...   - Complete tasks with INDEXes
+	t delete (INDEX) [INDEX] ... - Delete tasks with INDEXes
+	t log --done [--since 7d]    - Show completed tasks, with --all from all namespaces
+	t recur (INDEX) [RULE]       - Show or set recurrence rule of task
+	t pri (INDEX) (A-Z|none)     - Set priority of task, A is highest
+	t pin (INDEX) [INDEX] ...    - Pin tasks to top of namespace, marked by '*'
+	t unpin (INDEX) [INDEX] ...  - Unpin tasks
+	t order (INDEX) (POSITION)   - Move task to POSITION, edits don't change manual order,
+	                               new tasks are listed after ordered tasks
+	t top (INDEX)                - Move task to top, below pinned tasks
+	t bottom (INDEX)             - Move task to bottom, below it come only tasks added later
+	t start (INDEX)              - Start timer of task, running timer is stopped
+	t stop                       - Stop running timer
+	t time [--since 7d] [--by ns] - Show tracked time by task, ns or day, --all from all namespaces
+	t stats [NS] [--by day]      - Show open tasks, created and done per week or day, time to done
+	                               and oldest tasks of namespace with children, --all for all namespaces
+	t namespaces                 - Show namespaces as tree, counts include child namespaces
+	t ns rename (OLD) (NEW)      - Rename namespace with its child namespaces
+	t ns merge (SRC) (DST)       - Move tasks from SRC to DST, tasks with existing names get suffix
+	t ns rm (NS) [--force]       - Delete namespace, with --force if it has tasks
+	t tui                        - Interactive full screen mode
+	t export --markdown [NS] ... - Export namespaces as markdown, current by default, --all for all
+	t import --markdown (FILE)   - Import tasks from markdown FILE, '-' for stdin
+	t export --todotxt [NS] ...  - Export namespaces in todo.txt format
+	t import --todotxt (FILE)    - Import tasks from todo.txt FILE, '+project' is namespace
+	t export --ics [NS] ...      - Export namespaces as iCalendar VTODO components
+	t import --ics (FILE)        - Import VTODO components from iCalendar FILE, CATEGORIES is namespace
+	t import --taskwarrior (FILE) - Import output of 'task export', project is namespace
+	t export --csv [NS] ...      - Export namespaces as csv table, --include-deleted adds deleted tasks on sqlite
+	t encrypt [--names]          - Encrypt tasks of empty data directory, --names also encrypts names
+	t unlock [--timeout 8h]      - Cache key of encrypted tasks in agent until timeout
+	t lock                       - Forget cached key
+	t completion (SHELL)         - Print completion script for bash, zsh or fish
 	t --help                     - Show this message
 	t --version                  - Show version
+	t --dir (PATH) ...           - Use tasks from directory PATH
+	t --color (WHEN) ...         - Colorize output: auto, always or never
 
 	t a       - alias for add
 	t e       - alias for edit
 	t d       - alias for done
-	t delete  - alias for done
 	t ns      - alias for namespaces
 
 NAMESPACES
@@ -37,6 +82,30 @@ NAMESPACES
 
 	t <namespace> ...        # optional argument namespace before commands
 
+	t work/backend a fix api # namespaces can be nested by '/'
+	t work show -r           # show tasks of 'work', 'work/backend' and other children
+
+TIME TRACKING
+	Only one timer runs, it is shown next to its task and stops when task is done,
+	deleted or moved. Intervals are kept after task is done
+
+	t start 1                # start working on task 1
+	t time --since 1w --by day
+	t time --all --since 2026-01-01
+
+RECURRENCE
+	Recurring task is created again with its original content after done
+	Rules: daily, weekly (WEEKDAY), monthly [DAY], every (N) days, none.
+	Monthly task keeps its day of month, in shorter months it is due on last day
+
+	t recur 1 weekly mon     # repeat task 1 every monday
+	t recur 1 none           # stop repeating task 1
+
+COMPLETION
+	source <(t completion bash)             # bash, add to ~/.bashrc
+	source <(t completion zsh)              # zsh, add to ~/.zshrc
+	t completion fish | source              # fish
+
 NAMESPACE FILE
 	File with name '.tns' can be in current directory or any directory up the tree
 	File contains name of namespace
@@ -51,33 +120,191 @@ NAMESPACE FILE
 	$ t=storage t
 	# storage
 	...
+
+	Namespace is taken from command argument, then variable 't', then file '.tns',
+	then 'default_namespace' from config
+
+DATA DIRECTORY
+	Tasks are stored in '$XDG_DATA_HOME/t' (default '~/.local/share/t'),
+	flag --dir, then variable 'T_DIR', then 'data_dir' from config overwrite it.
+	Data from '~/.t' used by previous versions is moved on first run
+
+ENCRYPTION
+	Contents of tasks, and names with --names, are encrypted by AES-256-GCM
+	with key derived from passphrase. Namespaces stay plain.
+	Key is derived from variable 'T_PASSPHRASE', then taken from agent
+	started by 't unlock', else passphrase is asked
+
+	t --dir ~/secret encrypt # set up encryption of new data directory
+	t unlock                 # ask passphrase once
+	t export --markdown --all | t --dir ~/secret import --markdown -
+
+FORMAT
+	Template fields: .Index .Namespace .Name .FormattedName .LinesCount
+	.Priority .Pinned .Rank .TimerStarted .Created .Updated .Read .Preview, also accepted by 't all --format'
+
+	t show --format '{{.Index}}\t{{.Name}}\t{{.LinesCount}}'
+	t show --format '{{.Name}} {{.Updated.Format "2006-01-02"}}'
+	t show --format tmux      # named format from config
+
+HOOKS
+	Executables in directory 'hooks' next to config file (default '~/.config/t/hooks',
+	hooks from '~/.t/hooks' are moved there), 'hooks_dir' from config overwrites it,
+	named by event run around operations:
+	pre-add, post-add, pre-done, post-done, pre-edit, post-edit,
+	pre-delete, post-delete, pre-move, post-move
+	Pre hook exiting with non-zero status aborts operation, output goes to stderr
+
+	Environment: T_HOOK, T_NAMESPACE, T_TASK, T_TASK_FILE (file with task content,
+	not set for encrypted tasks),
+	T_DESTINATION (namespace of move)
+
+PLUGINS
+	Unknown command NAME runs executable 't-NAME' from PATH with rest of arguments,
+	plugin name as first argument takes precedence over namespace with the same name
+	Environment: T_NAMESPACE (also 't'), T_DIR, T_BACKEND, T_EXECUTABLE (path of t)
+
+	t standup                # runs t-standup, which can call '$T_EXECUTABLE log --json --since 1d'
+
+MARKDOWN
+	Heading '# NAMESPACE' starts namespace, heading '## TASK' starts task with body below it,
+	tasks before first namespace heading imported to current namespace
+
+	t export --markdown --all > tasks.md
+	t import --markdown tasks.md
+
+CONFIG
+	File '$XDG_CONFIG_HOME/t/config.toml' (default '~/.config/t/config.toml'),
+	environment variable 'T_CONFIG' overwrites its path
+
+	default_namespace = "def"     # namespace if no 't' variable and no '.tns' file
+	data_dir = "~/tasks"          # directory with tasks
+	backend = "fs"                # fs or sqlite, sqlite requires build with tag tsqlite
+	editor = "vim"                # overwrites $EDITOR
+	sort = "updated"              # updated, created (sqlite only), name, lines or priority, 'name reverse' inverts
+	recursive = false             # show tasks of child namespaces too
+	hooks_dir = "~/hooks"         # directory with hooks, see HOOKS
+	lines_count_limit = 70        # tasks with more lines shown as (...)
+
+	color = "auto"                # auto, always or never, auto disabled by NO_COLOR variable
+
+	[colors]                      # ANSI SGR parameters, empty disables color
+	heading = "1;34"
+	recurrence = "2"
+	overdue = "31"                # recurring tasks with due date in past
+	priority = "1;33"
+	pinned = "1;35"
+	timer = "32"                  # running timer
+
+	[sort]                        # default sort of namespace
+	work = "priority"
+
+	[formats]                     # named formats for --format
+	tmux = "{{.Index}}:{{.Name}}"
+
+	[alias]                       # can't overwrite builtin commands
+	ls = "show"
+	today = "log --done --since 1d"
 `
 
+const DEFAULT_EDITOR = "vi"
+
+// Shown before names of pinned tasks
+const PIN_MARKER = "*"
+
+// Settings overwritten by user config
+var (
+	Editor          = os.Getenv("EDITOR")
+	LinesCountLimit = config.DEFAULT_LINES_COUNT_LIMIT
+)
+
 type TaskView struct {
+	Index               int
 	Namespace           string
 	LinesCount          int
 	FormattedLinesCount string
 	Name                string
 	FormattedName       string
+	FormattedRecurrence string
+	Priority            string
+	Pinned              bool
+	Rank                int
+	TimerStarted        time.Time
+	Overdue             bool
+	Created             time.Time
+	Updated             time.Time
+	Read                time.Time
+	Preview             string
 }
 
-func ShowTasks(namespace string, s storage.TasksStorage) error {
-	tasks, err := s.GetSorted(namespace)
+func ShowTasks(w io.Writer, namespace string, s storage.TasksStorage) error {
+	return ShowTasksSorted(w, namespace, storage.SortOrder{}, s)
+}
+
+// Shows tasks in order, indexes are from default order of namespace, so they can be used by other commands
+func ShowTasksSorted(w io.Writer, namespace string, order storage.SortOrder, s storage.TasksStorage) error {
+	tasks, err := ListTasksSorted(namespace, order, s)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("\033[1;34m# %s\033[0m\n", namespace)
+	fmt.Fprintf(w, "%s\n", colorize("heading", "# "+namespace))
+	for _, tv := range tasks {
+		fmt.Fprintf(w, "[%d] %s (%s)%s%s\n", tv.Index, colorizeName(tv), tv.FormattedLinesCount, colorize("recurrence", tv.FormattedRecurrence), colorizeTimer(tv))
+	}
+
+	return nil
+}
+
+func ListTasks(namespace string, s storage.TasksStorage) ([]TaskView, error) {
+	return ListTasksSorted(namespace, storage.SortOrder{}, s)
+}
+
+// Returns tasks in order, empty order is default order of namespace with pinned and manually ordered tasks,
+// indexes are always from default order
+func ListTasksSorted(namespace string, order storage.SortOrder, s storage.TasksStorage) ([]TaskView, error) {
+	tasks, err := s.GetSorted(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := s.GetActiveTimer()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]TaskView, 0, len(tasks))
 	for i, task := range tasks {
 		tv := formatTaskView(namespace, task, s)
-		fmt.Printf("[%d] %s (%s)\n", i+1, tv.FormattedName, tv.FormattedLinesCount)
+		tv.Index = i + 1
+		if active != nil && active.Namespace == namespace && active.Name == task {
+			tv.TimerStarted = active.Start
+		}
+		result = append(result, tv)
 	}
 
-	return nil
+	if order.By == "" {
+		return result, nil
+	}
+
+	sorted, err := s.GetSortedBy(namespace, order)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make(map[string]int, len(sorted))
+	for i, task := range sorted {
+		positions[task] = i
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return positions[result[i].Name] < positions[result[j].Name]
+	})
+	return result, nil
 }
 
 func formatLinesCount(lines int) string {
-	if lines > 70 {
+	if lines > LinesCountLimit {
 		return "..."
 	}
 	if lines == 0 {
@@ -87,11 +314,297 @@ func formatLinesCount(lines int) string {
 }
 
 func AddTask(namespace string, name string, s storage.TasksStorage) error {
-	return s.Add(namespace, name)
+	tasks := []hookTask{{name: name}}
+
+	err := runPreHooks(HOOK_PRE_ADD, namespace, tasks)
+	if err != nil {
+		return err
+	}
+
+	err = s.Add(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	runPostHooks(HOOK_POST_ADD, namespace, tasks)
+	return nil
 }
 
 func DeleteTasksByIndexes(namespace string, indexes []int, s storage.TasksStorage) error {
-	return s.DeleteByIndexes(namespace, indexes)
+	tasks, err := hookTasks(namespace, indexes, s, HOOK_PRE_DELETE, HOOK_POST_DELETE)
+	if err != nil {
+		return err
+	}
+
+	err = runPreHooks(HOOK_PRE_DELETE, namespace, tasks)
+	if err != nil {
+		return err
+	}
+
+	err = stopTimerByIndexes(namespace, indexes, s)
+	if err != nil {
+		return err
+	}
+
+	err = s.DeleteByIndexes(namespace, indexes)
+	if err != nil {
+		return err
+	}
+
+	runPostHooks(HOOK_POST_DELETE, namespace, tasks)
+	return nil
+}
+
+func CompleteTasksByIndexes(namespace string, indexes []int, s storage.TasksStorage) error {
+	tasks, err := hookTasks(namespace, indexes, s, HOOK_PRE_DONE, HOOK_POST_DONE)
+	if err != nil {
+		return err
+	}
+
+	err = runPreHooks(HOOK_PRE_DONE, namespace, tasks)
+	if err != nil {
+		return err
+	}
+
+	err = stopTimerByIndexes(namespace, indexes, s)
+	if err != nil {
+		return err
+	}
+
+	err = s.CompleteByIndexes(namespace, indexes)
+	if err != nil {
+		return err
+	}
+
+	runPostHooks(HOOK_POST_DONE, namespace, tasks)
+	return nil
+}
+
+// Moves task with its recurrence to another namespace
+func MoveTaskByIndex(namespace string, index int, destination string, s storage.TasksStorage) error {
+	if namespace == destination {
+		return nil
+	}
+
+	taskName, err := s.GetNameByIndex(namespace, index)
+	if err != nil {
+		return err
+	}
+
+	content, err := s.GetContentByIndex(namespace, index)
+	if err != nil {
+		return err
+	}
+
+	r, err := s.GetRecurrence(namespace, taskName)
+	if err != nil {
+		return err
+	}
+
+	info, err := s.GetInfo(namespace, taskName)
+	if err != nil {
+		return err
+	}
+
+	tasks := []hookTask{{name: taskName, content: content}}
+	destinationEnv := "T_DESTINATION=" + destination
+
+	err = runPreHooks(HOOK_PRE_MOVE, namespace, tasks, destinationEnv)
+	if err != nil {
+		return err
+	}
+
+	err = s.Add(destination, taskName)
+	if err != nil {
+		return err
+	}
+
+	err = s.WriteByName(destination, taskName, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+
+	err = s.SetRecurrence(destination, taskName, r)
+	if err != nil {
+		return err
+	}
+
+	err = s.SetPriority(destination, taskName, info.Priority)
+	if err != nil {
+		return err
+	}
+
+	err = s.SetPinned(destination, taskName, !info.Pinned.IsZero())
+	if err != nil {
+		return err
+	}
+
+	err = stopTimerByIndexes(namespace, []int{index}, s)
+	if err != nil {
+		return err
+	}
+
+	err = s.DeleteByIndexes(namespace, []int{index})
+	if err != nil {
+		return err
+	}
+
+	runPostHooks(HOOK_POST_MOVE, namespace, tasks, destinationEnv)
+	return nil
+}
+
+// Shows tasks completed after since, from all namespaces if namespace is empty
+func ShowDoneLog(w io.Writer, namespace string, since time.Time, s storage.TasksStorage) error {
+	doneTasks, err := s.GetDone(namespace, since)
+	if err != nil {
+		return err
+	}
+
+	if namespace != "" {
+		fmt.Fprintf(w, "%s\n", colorize("heading", "# "+namespace))
+	}
+
+	for _, task := range doneTasks {
+		name := strings.ReplaceAll(task.Name, PATH_SEPARATOR_REPLACER, "/")
+		if namespace == "" {
+			fmt.Fprintf(w, "%s [%s] %s\n", task.DoneAt.Format("2006-01-02 15:04"), task.Namespace, name)
+			continue
+		}
+		fmt.Fprintf(w, "%s %s\n", task.DoneAt.Format("2006-01-02 15:04"), name)
+	}
+
+	return nil
+}
+
+func ShowRecurrenceByIndex(w io.Writer, namespace string, index int, s storage.TasksStorage) error {
+	taskName, err := s.GetNameByIndex(namespace, index)
+	if err != nil {
+		return err
+	}
+
+	r, err := s.GetRecurrence(namespace, taskName)
+	if err != nil {
+		return err
+	}
+
+	if r == nil {
+		fmt.Fprintln(w, "none")
+		return nil
+	}
+
+	fmt.Fprintf(w, "%s, due %s\n", r.Rule, r.Due.Format("Mon 2006-01-02"))
+	return nil
+}
+
+func SetPriorityByIndex(namespace string, index int, priority string, s storage.TasksStorage) error {
+	taskName, err := s.GetNameByIndex(namespace, index)
+	if err != nil {
+		return err
+	}
+
+	priority = strings.ToUpper(priority)
+	if priority == "NONE" {
+		priority = ""
+	}
+
+	if len(priority) > 1 || (priority != "" && (priority[0] < 'A' || priority[0] > 'Z')) {
+		return fmt.Errorf("Priority must be letter from A to Z or 'none'")
+	}
+
+	return s.SetPriority(namespace, taskName, priority)
+}
+
+// Pinned tasks are shown first in order of pinning, pin doesn't change updated time of task
+func PinTasksByIndexes(namespace string, indexes []int, pinned bool, s storage.TasksStorage) error {
+	names := make([]string, 0, len(indexes))
+	for _, index := range indexes {
+		name, err := s.GetNameByIndex(namespace, index)
+		if err != nil {
+			return err
+		}
+		names = append(names, name)
+	}
+
+	for _, name := range names {
+		err := s.SetPinned(namespace, name, pinned)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Moves task to position in list and stores order of all not pinned tasks,
+// so later edits don't reorder them, tasks added later are listed after ordered ones
+func OrderTaskByIndex(namespace string, index int, position int, s storage.TasksStorage) error {
+	tasks, err := s.GetSorted(namespace)
+	if err != nil {
+		return err
+	}
+
+	if index > len(tasks) || index < 1 {
+		return fmt.Errorf("Wrong task index: %d", index)
+	}
+
+	pinnedCount := 0
+	ordered := make([]string, 0, len(tasks))
+	for i, task := range tasks {
+		info, err := s.GetInfo(namespace, task)
+		if err != nil {
+			return err
+		}
+
+		if !info.Pinned.IsZero() {
+			if i == index-1 {
+				return fmt.Errorf("Task %d is pinned, unpin it to change order", index)
+			}
+			pinnedCount++
+			continue
+		}
+
+		if i != index-1 {
+			ordered = append(ordered, task)
+		}
+	}
+
+	position = min(max(position-pinnedCount, 1), len(ordered)+1)
+	ordered = append(ordered[:position-1], append([]string{tasks[index-1]}, ordered[position-1:]...)...)
+
+	for i, task := range ordered {
+		err = s.SetRank(namespace, task, i+1)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func SetRecurrenceByIndex(namespace string, index int, rawRule string, s storage.TasksStorage) error {
+	taskName, err := s.GetNameByIndex(namespace, index)
+	if err != nil {
+		return err
+	}
+
+	if rawRule == "none" {
+		return s.SetRecurrence(namespace, taskName, nil)
+	}
+
+	rule, err := recurrence.Parse(rawRule)
+	if err != nil {
+		return err
+	}
+	rule = rule.Anchored(time.Now())
+
+	template, err := s.GetContentByIndex(namespace, index)
+	if err != nil {
+		return err
+	}
+
+	return s.SetRecurrence(namespace, taskName, &storage.Recurrence{
+		Rule:     rule.String(),
+		Due:      rule.First(time.Now()),
+		Template: template,
+	})
 }
 
 func EditTaskByIndex(namespace string, index int, s storage.TasksStorage) error {
@@ -100,23 +613,46 @@ func EditTaskByIndex(namespace string, index int, s storage.TasksStorage) error
 		return err
 	}
 
-	tempFile, err := createTempFile(fmt.Sprintf("t_%s_", strings.ReplaceAll(taskName, "/", PATH_SEPARATOR_REPLACER)))
+	// editor arguments are visible to other users, so name of encrypted task is hidden
+	tempName := strings.ReplaceAll(taskName, "/", PATH_SEPARATOR_REPLACER)
+	_, encrypted := s.(*storage.EncryptedTasksStorage)
+	if encrypted {
+		tempName = "task"
+	}
+
+	tempFile, cleanup, err := createPrivateTempFile(tempName)
 	if err != nil {
 		return err
 	}
+	defer cleanup()
 
 	content, err := s.GetContentByIndex(namespace, index)
 	if err != nil {
+		tempFile.Close()
+		return err
+	}
+
+	err = runHook(HOOK_PRE_EDIT, namespace, hookTask{name: taskName, content: content})
+	if err != nil {
+		tempFile.Close()
 		return err
 	}
 
 	_, err = tempFile.Write(content) // write original text from task
+	tempFile.Close()                 // close now, because of editor
+	if err != nil {
+		return err
+	}
+
+	editorArgs, err := config.SplitArgs(Editor)
 	if err != nil {
 		return err
 	}
-	tempFile.Close() // close now, because of editor
+	if len(editorArgs) == 0 {
+		editorArgs = []string{DEFAULT_EDITOR}
+	}
 
-	cmd := exec.Command(os.Getenv("EDITOR"), tempFile.Name())
+	cmd := exec.Command(editorArgs[0], append(editorArgs[1:], tempFile.Name())...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -130,20 +666,43 @@ func EditTaskByIndex(namespace string, index int, s storage.TasksStorage) error
 	if err != nil {
 		return err
 	}
-	defer os.Remove(tempFile.Name())
 	defer tempFile.Close()
 
-	return s.WriteByName(namespace, taskName, tempFile)
+	err = s.WriteByName(namespace, taskName, tempFile)
+	if err != nil {
+		return err
+	}
+
+	if hooksExist(HOOK_POST_EDIT) {
+		content, err = s.GetContentByName(namespace, taskName)
+		if err != nil {
+			return err
+		}
+		runPostHooks(HOOK_POST_EDIT, namespace, []hookTask{{name: taskName, content: content}})
+	}
+	return nil
 }
 
-func createTempFile(pattern string) (*os.File, error) {
-	tempDir := getTempDir()
+// Creates file readable only by user in new directory, cleanup removes directory with file.
+// Directory $XDG_RUNTIME_DIR is preferred, because it is usually in memory and not backed up
+func createPrivateTempFile(name string) (*os.File, func(), error) {
+	tempDir := os.Getenv("XDG_RUNTIME_DIR")
+	if tempDir == "" || !exists(tempDir) {
+		tempDir = getTempDir()
+	}
+
+	dir, err := os.MkdirTemp(tempDir, "t_")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
 
-	tempFile, err := os.CreateTemp(tempDir, pattern)
+	tempFile, err := os.OpenFile(path.Join(dir, name), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
 	if err != nil {
-		return nil, err
+		cleanup()
+		return nil, nil, err
 	}
-	return tempFile, nil
+	return tempFile, cleanup, nil
 }
 
 func getTempDir() string {
@@ -168,17 +727,17 @@ func exists(path string) bool {
 	return true
 }
 
-func ShowTaskContentByName(namespace string, name string, s storage.TasksStorage) error {
+func ShowTaskContentByName(w io.Writer, namespace string, name string, s storage.TasksStorage) error {
 	content, err := s.GetContentByName(namespace, name)
 	if err != nil {
 		return err
 	}
 
-	fmt.Print(string(content))
-	return nil
+	_, err = w.Write(content)
+	return err
 }
 
-func ShowTaskContentByIndex(namespace string, index int, s storage.TasksStorage) error {
+func ShowTaskContentByIndex(w io.Writer, namespace string, index int, s storage.TasksStorage) error {
 	taskContent, err := s.GetContentByIndex(namespace, index)
 	taskName, err := s.GetNameByIndex(namespace, index)
 	taskName = strings.ReplaceAll(taskName, PATH_SEPARATOR_REPLACER, "/")
@@ -187,35 +746,252 @@ func ShowTaskContentByIndex(namespace string, index int, s storage.TasksStorage)
 		return err
 	}
 
-	fmt.Printf("\033[1;34m# %s\033[0m\n\n", taskName)
-	fmt.Print(string(taskContent))
-	return nil
+	fmt.Fprintf(w, "%s\n\n", colorize("heading", "# "+taskName))
+	_, err = w.Write(taskContent)
+	return err
 }
 
-func ShowNamespaces(s storage.TasksStorage) error {
-	nss, err := s.GetNamespaces()
+// Namespace in tree of namespaces, Total includes tasks of child namespaces,
+// Count is -1 if it can't be read
+type NamespaceView struct {
+	Namespace string
+	Name      string
+	Depth     int
+	Count     int
+	Total     int
+}
 
+// Returns namespaces sorted as tree, parents without own tasks are included
+func NamespaceTree(s storage.TasksStorage) ([]NamespaceView, error) {
+	nss, err := s.GetNamespaces()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	counts := map[string]int{}
+	totals := map[string]int{}
+
 	for _, ns := range nss {
 		namespaceTasksCount, err := s.Count(ns)
 		if err != nil {
-			fmt.Printf("%s (%s)\n", ns, "-")
+			counts[ns] = -1
+			continue
+		}
+
+		for _, parent := range namespaceAncestors(ns) {
+			totals[parent] += namespaceTasksCount
+		}
+		totals[ns] += namespaceTasksCount
+		counts[ns] = namespaceTasksCount
+	}
+
+	tree := make([]string, 0, len(totals))
+	for ns := range totals {
+		tree = append(tree, ns)
+	}
+	for ns := range counts {
+		if _, found := totals[ns]; !found {
+			tree = append(tree, ns)
+		}
+	}
+	sort.Strings(tree)
+
+	result := make([]NamespaceView, 0, len(tree))
+	for _, ns := range tree {
+		result = append(result, NamespaceView{
+			Namespace: ns,
+			Name:      ns[strings.LastIndex(ns, "/")+1:],
+			Depth:     strings.Count(ns, "/"),
+			Count:     counts[ns],
+			Total:     totals[ns],
+		})
+	}
+	return result, nil
+}
+
+// Shows namespaces as tree, count of namespace includes tasks of child namespaces
+func ShowNamespaces(w io.Writer, s storage.TasksStorage) error {
+	tree, err := NamespaceTree(s)
+	if err != nil {
+		return err
+	}
+
+	for _, nv := range tree {
+		indent := strings.Repeat("  ", nv.Depth)
+
+		if nv.Count < 0 {
+			fmt.Fprintf(w, "%s%s (%s)\n", indent, nv.Name, "-")
+			continue
+		}
+		fmt.Fprintf(w, "%s%s (%d)\n", indent, nv.Name, nv.Total)
+	}
+	return nil
+}
+
+// Shows tasks of namespace and tasks of its child namespaces with tasks
+func ShowTasksWithChildren(w io.Writer, namespace string, order storage.SortOrder, s storage.TasksStorage) error {
+	namespaces, err := WithChildren(namespace, s)
+	if err != nil {
+		return err
+	}
+
+	for _, ns := range namespaces {
+		count, err := s.Count(ns)
+		if err != nil {
+			return err
+		}
+
+		if ns != namespace && count == 0 {
 			continue
 		}
-		fmt.Printf("%s (%d)\n", ns, namespaceTasksCount)
+
+		err = ShowTasksSorted(w, ns, order, s)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Returns namespace followed by its child namespaces of all levels
+func WithChildren(namespace string, s storage.TasksStorage) ([]string, error) {
+	nss, err := s.GetNamespaces()
+	if err != nil {
+		return nil, err
+	}
+
+	children := []string{}
+	for _, ns := range nss {
+		if strings.HasPrefix(ns, namespace+"/") {
+			children = append(children, ns)
+		}
+	}
+	sort.Strings(children)
+
+	return append([]string{namespace}, children...), nil
+}
+
+// Returns parents of namespace 'a/b/c': 'a' and 'a/b'
+func namespaceAncestors(namespace string) []string {
+	var result []string
+	for i, c := range namespace {
+		if c == '/' {
+			result = append(result, namespace[:i])
+		}
+	}
+	return result
+}
+
+// Renames namespace with its child namespaces
+func RenameNamespace(old string, new string, s storage.TasksStorage) error {
+	err := ValidateNamespace(old)
+	if err != nil {
+		return err
+	}
+
+	err = ValidateNamespace(new)
+	if err != nil {
+		return err
+	}
+
+	if old == new {
+		return nil
+	}
+
+	if strings.HasPrefix(new, old+"/") {
+		return fmt.Errorf("Can't move namespace '%s' into its child '%s'", old, new)
+	}
+
+	return s.RenameNamespace(old, new)
+}
+
+// Moves tasks from src to dst, tasks with names existing in dst are renamed,
+// returns new names of renamed tasks
+func MergeNamespaces(src string, dst string, s storage.TasksStorage) (map[string]string, error) {
+	err := ValidateNamespace(src)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ValidateNamespace(dst)
+	if err != nil {
+		return nil, err
+	}
+
+	if src == dst {
+		return nil, fmt.Errorf("Can't merge namespace '%s' into itself", src)
+	}
+
+	renamed, err := s.MergeNamespace(src, dst)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string, len(renamed))
+	for name, newName := range renamed {
+		result[strings.ReplaceAll(name, PATH_SEPARATOR_REPLACER, "/")] = strings.ReplaceAll(newName, PATH_SEPARATOR_REPLACER, "/")
+	}
+	return result, nil
+}
+
+func ShowRenamedTasks(w io.Writer, renamed map[string]string) {
+	names := make([]string, 0, len(renamed))
+	for name := range renamed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(w, "Task '%s' renamed to '%s'\n", name, renamed[name])
+	}
+}
+
+// Deletes namespace, namespace with tasks is deleted only with force
+func DeleteNamespace(namespace string, force bool, s storage.TasksStorage) error {
+	err := ValidateNamespace(namespace)
+	if err != nil {
+		return err
+	}
+
+	count, err := s.Count(namespace)
+	if err != nil {
+		return fmt.Errorf("Namespace '%s' not found", namespace)
+	}
+
+	if count > 0 && !force {
+		return fmt.Errorf("Namespace '%s' has %d tasks, use --force to delete it", namespace, count)
+	}
+
+	err = stopTimerOfNamespace(namespace, s)
+	if err != nil {
+		return err
+	}
+
+	return s.DeleteNamespace(namespace)
+}
+
+// Namespace is path of names separated by '/', names can't be empty or start with '.'
+func ValidateNamespace(namespace string) error {
+	if namespace == "" {
+		return fmt.Errorf("Namespace name is empty")
+	}
+
+	for _, name := range strings.Split(namespace, "/") {
+		if name == "" || strings.HasPrefix(name, ".") {
+			return fmt.Errorf("Invalid namespace '%s', names separated by '/' can't be empty or start with '.'", namespace)
+		}
 	}
+
 	return nil
 }
 
-func ShowHelp() error {
-	_, err := fmt.Print(HELP_MESSAGE)
+func ShowHelp(w io.Writer) error {
+	_, err := fmt.Fprint(w, HELP_MESSAGE)
 	return err
 }
 
-func ShowAllTasksFromAllNamespaces(s storage.TasksStorage) error {
+func ShowAllTasksFromAllNamespaces(w io.Writer, s storage.TasksStorage) error {
 	namespaces, err := s.GetNamespaces()
 	if err != nil {
 		return err
@@ -228,7 +1004,7 @@ func ShowAllTasksFromAllNamespaces(s storage.TasksStorage) error {
 		}
 		for _, task := range currentNamespaceTasks {
 			tv := formatTaskView(namespace, task, s)
-			fmt.Printf("[%s] %s (%s)\n", tv.Namespace, tv.FormattedName, tv.FormattedLinesCount)
+			fmt.Fprintf(w, "[%s] %s (%s)%s\n", tv.Namespace, colorizeName(tv), tv.FormattedLinesCount, colorize("recurrence", tv.FormattedRecurrence))
 		}
 	}
 	return nil
@@ -247,5 +1023,54 @@ func formatTaskView(namespace string, task string, s storage.TasksStorage) TaskV
 	tv.Namespace = namespace
 	tv.FormattedName = strings.ReplaceAll(task, PATH_SEPARATOR_REPLACER, "/")
 
+	info, err := s.GetInfo(namespace, task)
+	if err == nil {
+		tv.Priority = info.Priority
+		tv.Pinned = !info.Pinned.IsZero()
+		tv.Rank = info.Rank
+		tv.Created = info.Created
+		tv.Updated = info.Updated
+		tv.Read = info.Read
+		tv.Preview = info.Preview
+	}
+
+	r, err := s.GetRecurrence(namespace, task)
+	if err == nil && r != nil {
+		tv.FormattedRecurrence = formatRecurrence(r)
+		tv.Overdue = r.Due.Before(today())
+	}
+
 	return tv
 }
+
+func colorizeName(tv TaskView) string {
+	name := tv.FormattedName
+	if tv.Overdue {
+		name = colorize("overdue", name)
+	}
+
+	if tv.Priority != "" {
+		name = colorize("priority", "("+tv.Priority+")") + " " + name
+	}
+
+	if tv.Pinned {
+		name = colorize("pinned", PIN_MARKER) + " " + name
+	}
+	return name
+}
+
+func colorizeTimer(tv TaskView) string {
+	if tv.TimerStarted.IsZero() {
+		return ""
+	}
+	return colorize("timer", formatTimer(tv.TimerStarted))
+}
+
+func today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
+
+func formatRecurrence(r *storage.Recurrence) string {
+	return fmt.Sprintf(" [%s, due %s]", r.Rule, r.Due.Format("Mon 2006-01-02"))
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	storage "github.com/thek4n/t/internal/storage"
+)
+
+// Hooks are executables in HooksDir named by event, pre hook exiting with non-zero status aborts operation
+const (
+	HOOK_PRE_ADD     = "pre-add"
+	HOOK_POST_ADD    = "post-add"
+	HOOK_PRE_DONE    = "pre-done"
+	HOOK_POST_DONE   = "post-done"
+	HOOK_PRE_EDIT    = "pre-edit"
+	HOOK_POST_EDIT   = "post-edit"
+	HOOK_PRE_DELETE  = "pre-delete"
+	HOOK_POST_DELETE = "post-delete"
+	HOOK_PRE_MOVE    = "pre-move"
+	HOOK_POST_MOVE   = "post-move"
+)
+
+// Directory with hooks, empty disables hooks
+var HooksDir string
+
+// Content of task is given to hooks by T_TASK_FILE, disabled for encrypted tasks,
+// so decrypted content isn't written to file which hook can keep
+var HookTaskFile = true
+
+type hookTask struct {
+	name    string
+	content []byte
+}
+
+func hookPath(hook string) string {
+	if HooksDir == "" {
+		return ""
+	}
+
+	hookPath := path.Join(HooksDir, hook)
+	info, err := os.Stat(hookPath)
+	if err != nil || info.IsDir() || info.Mode().Perm()&0111 == 0 {
+		return ""
+	}
+	return hookPath
+}
+
+func hooksExist(hooks ...string) bool {
+	for _, hook := range hooks {
+		if hookPath(hook) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns names and contents of tasks by indexes, nothing if none of hooks exists
+func hookTasks(namespace string, indexes []int, s storage.TasksStorage, hooks ...string) ([]hookTask, error) {
+	if !hooksExist(hooks...) {
+		return nil, nil
+	}
+
+	tasks := make([]hookTask, 0, len(indexes))
+	for _, index := range indexes {
+		name, err := s.GetNameByIndex(namespace, index)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := s.GetContentByName(namespace, name)
+		if err != nil {
+			return nil, err
+		}
+
+		tasks = append(tasks, hookTask{name: name, content: content})
+	}
+	return tasks, nil
+}
+
+// Runs hook with task in environment:
+//
+//	T_HOOK       name of hook
+//	T_NAMESPACE  namespace of task
+//	T_TASK       name of task
+//	T_TASK_FILE  temporary file with content of task, not set if HookTaskFile is disabled
+func runHook(hook string, namespace string, task hookTask, env ...string) error {
+	hookPath := hookPath(hook)
+	if hookPath == "" {
+		return nil
+	}
+
+	cmd := exec.Command(hookPath)
+	cmd.Env = append(os.Environ(),
+		"T_HOOK="+hook,
+		"T_NAMESPACE="+namespace,
+		"T_TASK="+strings.ReplaceAll(task.name, PATH_SEPARATOR_REPLACER, "/"),
+	)
+
+	if HookTaskFile {
+		contentFile, cleanup, err := createPrivateTempFile("task")
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		_, err = contentFile.Write(task.content)
+		contentFile.Close()
+		if err != nil {
+			return err
+		}
+
+		cmd.Env = append(cmd.Env, "T_TASK_FILE="+contentFile.Name())
+	}
+	cmd.Env = append(cmd.Env, env...)
+	cmd.Stdout = os.Stderr // keep stdout of command clean
+	cmd.Stderr = os.Stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("Hook %s failed on task '%s': %s", hook, strings.ReplaceAll(task.name, PATH_SEPARATOR_REPLACER, "/"), err)
+	}
+	return nil
+}
+
+func runPreHooks(hook string, namespace string, tasks []hookTask, env ...string) error {
+	for _, task := range tasks {
+		err := runHook(hook, namespace, task, env...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Operation is already done, so failed post hook is only reported
+func runPostHooks(hook string, namespace string, tasks []hookTask, env ...string) {
+	for _, task := range tasks {
+		err := runHook(hook, namespace, task, env...)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+		}
+	}
+}
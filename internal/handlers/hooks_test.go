@@ -0,0 +1,77 @@
+package handlers_test
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	handlers "github.com/thek4n/t/internal/handlers"
+)
+
+func TestFailingPreHookAbortsDelete(t *testing.T) {
+	s, _ := newFSStorage(t)
+
+	hooksDir := t.TempDir()
+	err := os.WriteFile(path.Join(hooksDir, handlers.HOOK_PRE_DELETE), []byte("#!/bin/sh\nexit 1\n"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handlers.HooksDir = hooksDir
+	defer func() { handlers.HooksDir = "" }()
+
+	err = s.Add("def", "keep me")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = handlers.DeleteTasksByIndexes("def", []int{1}, s)
+	if err == nil {
+		t.Fatal("expected error of failing pre-delete hook")
+	}
+
+	count, err := s.Count("def")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("task expected to stay after failing pre-delete hook, got %d tasks", count)
+	}
+}
+
+func TestHookWithoutTaskFile(t *testing.T) {
+	s, _ := newFSStorage(t)
+
+	hooksDir := t.TempDir()
+	output := path.Join(t.TempDir(), "output")
+	hook := "#!/bin/sh\necho \"${T_TASK_FILE-unset}\" > '" + output + "'\n"
+	err := os.WriteFile(path.Join(hooksDir, handlers.HOOK_POST_DONE), []byte(hook), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handlers.HooksDir = hooksDir
+	handlers.HookTaskFile = false
+	defer func() {
+		handlers.HooksDir = ""
+		handlers.HookTaskFile = true
+	}()
+
+	err = s.Add("def", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = handlers.CompleteTasksByIndexes("def", []int{1}, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "unset\n" {
+		t.Errorf("expected T_TASK_FILE not set, got %q", got)
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	storage "github.com/thek4n/t/internal/storage"
+)
+
+// JSON output is interface for scripts and plugins, fields can be added but not changed
+
+type taskJSON struct {
+	Index      int        `json:"index"`
+	Namespace  string     `json:"namespace"`
+	Name       string     `json:"name"`
+	Lines      int        `json:"lines"`
+	Priority   string     `json:"priority,omitempty"`
+	Pinned     bool       `json:"pinned"`
+	Rank       int        `json:"rank,omitempty"`
+	Timer      *time.Time `json:"timer_started,omitempty"`
+	Recurrence string     `json:"recurrence,omitempty"`
+	Due        *time.Time `json:"due,omitempty"`
+	Overdue    bool       `json:"overdue"`
+	Created    time.Time  `json:"created"`
+	Updated    time.Time  `json:"updated"`
+	Read       time.Time  `json:"read"`
+	Preview    string     `json:"preview"`
+}
+
+type namespaceJSON struct {
+	Namespace string `json:"namespace"`
+	Count     int    `json:"count"`
+	Total     int    `json:"total"`
+}
+
+type doneTaskJSON struct {
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	Created   time.Time `json:"created"`
+	DoneAt    time.Time `json:"done_at"`
+}
+
+func writeJSON(w io.Writer, value any) error {
+	return json.NewEncoder(w).Encode(value)
+}
+
+// Empty order is default order of namespace
+func ShowTasksJSON(w io.Writer, namespaces []string, order storage.SortOrder, s storage.TasksStorage) error {
+	result := []taskJSON{}
+
+	for _, namespace := range namespaces {
+		tasks, err := ListTasksSorted(namespace, order, s)
+		if err != nil {
+			return err
+		}
+
+		for _, tv := range tasks {
+			task := taskJSON{
+				Index:     tv.Index,
+				Namespace: tv.Namespace,
+				Name:      tv.FormattedName,
+				Lines:     tv.LinesCount,
+				Priority:  tv.Priority,
+				Pinned:    tv.Pinned,
+				Rank:      tv.Rank,
+				Overdue:   tv.Overdue,
+				Created:   tv.Created,
+				Updated:   tv.Updated,
+				Read:      tv.Read,
+				Preview:   tv.Preview,
+			}
+
+			if !tv.TimerStarted.IsZero() {
+				task.Timer = &tv.TimerStarted
+			}
+
+			r, err := s.GetRecurrence(namespace, tv.Name)
+			if err == nil && r != nil {
+				task.Recurrence = r.Rule
+				task.Due = &r.Due
+			}
+
+			result = append(result, task)
+		}
+	}
+
+	return writeJSON(w, result)
+}
+
+func ShowAllTasksJSON(w io.Writer, s storage.TasksStorage) error {
+	namespaces, err := s.GetNamespaces()
+	if err != nil {
+		return err
+	}
+
+	return ShowTasksJSON(w, namespaces, storage.SortOrder{}, s)
+}
+
+func ShowNamespacesJSON(w io.Writer, s storage.TasksStorage) error {
+	tree, err := NamespaceTree(s)
+	if err != nil {
+		return err
+	}
+
+	result := make([]namespaceJSON, 0, len(tree))
+	for _, nv := range tree {
+		result = append(result, namespaceJSON{Namespace: nv.Namespace, Count: nv.Count, Total: nv.Total})
+	}
+
+	return writeJSON(w, result)
+}
+
+// Shows tasks completed after since, from all namespaces if namespace is empty
+func ShowDoneLogJSON(w io.Writer, namespace string, since time.Time, s storage.TasksStorage) error {
+	doneTasks, err := s.GetDone(namespace, since)
+	if err != nil {
+		return err
+	}
+
+	result := make([]doneTaskJSON, 0, len(doneTasks))
+	for _, task := range doneTasks {
+		result = append(result, doneTaskJSON{
+			Namespace: task.Namespace,
+			Name:      strings.ReplaceAll(task.Name, PATH_SEPARATOR_REPLACER, "/"),
+			Created:   task.Created,
+			DoneAt:    task.DoneAt,
+		})
+	}
+
+	return writeJSON(w, result)
+}
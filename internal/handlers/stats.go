@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	storage "github.com/thek4n/t/internal/storage"
+)
+
+// Periods of statistics with count of shown periods
+const (
+	PERIOD_DAY  = "day"
+	PERIOD_WEEK = "week"
+)
+
+var PERIODS = map[string]int{
+	PERIOD_DAY:  14,
+	PERIOD_WEEK: 12,
+}
+
+const OLDEST_TASKS_COUNT = 5
+
+// Levels of sparkline from no activity to maximum, ASCII so it works in any terminal
+const SPARKLINE_LEVELS = "_.-=+*#@"
+
+type NamespaceCount struct {
+	Namespace string
+	Count     int
+}
+
+// Tasks created and done in period starting at Start
+type PeriodStats struct {
+	Start   time.Time
+	Created int
+	Done    int
+}
+
+// Statistics of namespace with its child namespaces, of all namespaces if namespace is empty.
+// File systems keep only time of last modification, so on fs backend edited task counts as created at edit
+type Stats struct {
+	Open           []NamespaceCount
+	OpenTasksCount int
+	Periods        []PeriodStats
+	DoneCount      int
+	AverageToDone  time.Duration
+	OldestOpen     []TaskView
+}
+
+func CollectStats(namespace string, period string, now time.Time, s storage.TasksStorage) (Stats, error) {
+	periodsCount, found := PERIODS[period]
+	if !found {
+		return Stats{}, fmt.Errorf("Unknown period '%s', expected %s or %s", period, PERIOD_DAY, PERIOD_WEEK)
+	}
+
+	stats := Stats{Periods: make([]PeriodStats, periodsCount)}
+	periods := map[string]*PeriodStats{}
+	start := periodStart(now, period)
+	for i := range stats.Periods {
+		stats.Periods[i].Start = shiftPeriod(start, period, i-periodsCount+1)
+		periods[stats.Periods[i].Start.Format("2006-01-02")] = &stats.Periods[i]
+	}
+
+	inPeriod := func(t time.Time) *PeriodStats {
+		return periods[periodStart(t.In(now.Location()), period).Format("2006-01-02")]
+	}
+
+	namespaces, err := s.GetNamespaces()
+	if err != nil {
+		return Stats{}, err
+	}
+	sort.Strings(namespaces)
+
+	open := []TaskView{}
+	for _, ns := range namespaces {
+		if !inNamespaceTree(ns, namespace) {
+			continue
+		}
+
+		tasks, err := ListTasks(ns, s)
+		if err != nil {
+			return Stats{}, err
+		}
+
+		if len(tasks) == 0 {
+			continue
+		}
+
+		stats.Open = append(stats.Open, NamespaceCount{Namespace: ns, Count: len(tasks)})
+		stats.OpenTasksCount += len(tasks)
+		open = append(open, tasks...)
+
+		for _, tv := range tasks {
+			ps := inPeriod(tv.Created)
+			if ps != nil {
+				ps.Created++
+			}
+		}
+	}
+
+	doneTasks, err := s.GetDone("", time.Time{})
+	if err != nil {
+		return Stats{}, err
+	}
+
+	var toDone time.Duration
+	for _, task := range doneTasks {
+		if !inNamespaceTree(task.Namespace, namespace) {
+			continue
+		}
+
+		ps := inPeriod(task.DoneAt)
+		if ps != nil {
+			ps.Done++
+		}
+
+		if task.Created.IsZero() {
+			continue
+		}
+
+		ps = inPeriod(task.Created)
+		if ps != nil {
+			ps.Created++
+		}
+
+		stats.DoneCount++
+		toDone += max(task.DoneAt.Sub(task.Created), 0)
+	}
+
+	if stats.DoneCount > 0 {
+		stats.AverageToDone = toDone / time.Duration(stats.DoneCount)
+	}
+
+	sort.SliceStable(open, func(i, j int) bool {
+		return open[i].Created.Before(open[j].Created)
+	})
+	stats.OldestOpen = open[:min(len(open), OLDEST_TASKS_COUNT)]
+
+	return stats, nil
+}
+
+// Shows open tasks per namespace, tasks created and done per period with sparkline of activity,
+// average time from creation to done and oldest open tasks
+func ShowStats(w io.Writer, namespace string, period string, s storage.TasksStorage) error {
+	now := time.Now()
+	stats, err := CollectStats(namespace, period, now, s)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "%s\n", colorize("heading", "# Open tasks"))
+	width := len("total")
+	for _, nc := range stats.Open {
+		width = max(width, len(nc.Namespace))
+	}
+	for _, nc := range stats.Open {
+		fmt.Fprintf(w, "%-*s  %d\n", width, nc.Namespace, nc.Count)
+	}
+	fmt.Fprintf(w, "%-*s  %d\n", width, "total", stats.OpenTasksCount)
+
+	fmt.Fprintf(w, "\n%s\n", colorize("heading", "# Created and done per "+period))
+	activity := make([]int, 0, len(stats.Periods))
+	for _, ps := range stats.Periods {
+		fmt.Fprintf(w, "%s  +%-3d -%d\n", ps.Start.Format("2006-01-02 Mon"), ps.Created, ps.Done)
+		activity = append(activity, ps.Created+ps.Done)
+	}
+	fmt.Fprintf(w, "activity        %s\n", sparkline(activity))
+
+	fmt.Fprintf(w, "\n%s\n", colorize("heading", "# Time to done"))
+	if stats.DoneCount == 0 {
+		fmt.Fprintf(w, "no done tasks\n")
+	} else {
+		fmt.Fprintf(w, "average %s of %d tasks\n", formatAge(stats.AverageToDone), stats.DoneCount)
+	}
+
+	fmt.Fprintf(w, "\n%s\n", colorize("heading", "# Oldest open tasks"))
+	for _, tv := range stats.OldestOpen {
+		fmt.Fprintf(w, "%s  %6s  [%s] %s\n", tv.Created.Format("2006-01-02"), formatAge(now.Sub(tv.Created)), tv.Namespace, tv.FormattedName)
+	}
+
+	return nil
+}
+
+// Returns line with character per value, scaled to maximum value
+func sparkline(values []int) string {
+	maxValue := 0
+	for _, value := range values {
+		maxValue = max(maxValue, value)
+	}
+
+	var sb strings.Builder
+	for _, value := range values {
+		level := 0
+		if maxValue > 0 {
+			level = value * (len(SPARKLINE_LEVELS) - 1) / maxValue
+		}
+		sb.WriteByte(SPARKLINE_LEVELS[level])
+	}
+	return sb.String()
+}
+
+func periodStart(t time.Time, period string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if period == PERIOD_WEEK {
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7) // weeks start on monday
+	}
+	return day
+}
+
+func shiftPeriod(start time.Time, period string, n int) time.Time {
+	if period == PERIOD_WEEK {
+		return start.AddDate(0, 0, 7*n)
+	}
+	return start.AddDate(0, 0, n)
+}
+
+// Formats duration as '3d4h' if it is longer than day
+func formatAge(d time.Duration) string {
+	if d < 24*time.Hour {
+		return formatDuration(d)
+	}
+
+	days := int(d.Hours()) / 24
+	return fmt.Sprintf("%dd%dh", days, int(d.Hours())%24)
+}
+
+// Empty namespace is root of all namespaces
+func inNamespaceTree(namespace string, root string) bool {
+	return root == "" || namespace == root || strings.HasPrefix(namespace, root+"/")
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+)
+
+const COLOR_AUTO = "auto"
+const COLOR_ALWAYS = "always"
+const COLOR_NEVER = "never"
+
+// Styles of output elements as ANSI SGR parameters, empty style disables color of element
+var Colors = map[string]string{
+	"heading":    "1;34",
+	"recurrence": "2",
+	"overdue":    "31",
+	"priority":   "1;33",
+	"pinned":     "1;35",
+	"timer":      "32",
+}
+
+// Colors are disabled until SetColorMode called, so library users get plain text
+var colorsEnabled = false
+
+// Mode 'auto' enables colors only if stdout is terminal and NO_COLOR variable is not set
+func SetColorMode(mode string) error {
+	switch mode {
+	case COLOR_AUTO, "":
+		colorsEnabled = autoColors()
+	case COLOR_ALWAYS:
+		colorsEnabled = true
+	case COLOR_NEVER:
+		colorsEnabled = false
+	default:
+		return fmt.Errorf("Unknown color mode '%s', expected %s, %s or %s", mode, COLOR_AUTO, COLOR_ALWAYS, COLOR_NEVER)
+	}
+	return nil
+}
+
+func autoColors() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	info, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Wraps text in ANSI escape sequence of style with name from Colors
+func colorize(name string, text string) string {
+	color := Colors[name]
+	if !colorsEnabled || color == "" {
+		return text
+	}
+	return "\033[" + color + "m" + text + "\033[0m"
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	storage "github.com/thek4n/t/internal/storage"
+)
+
+// Groups of time report
+const (
+	TIME_BY_TASK      = "task"
+	TIME_BY_NAMESPACE = "ns"
+	TIME_BY_DAY       = "day"
+)
+
+var TIME_GROUPS = []string{TIME_BY_TASK, TIME_BY_NAMESPACE, TIME_BY_DAY}
+
+// Starts timer of task, running timer of other task is stopped
+func StartTimerByIndex(namespace string, index int, s storage.TasksStorage) error {
+	name, err := s.GetNameByIndex(namespace, index)
+	if err != nil {
+		return err
+	}
+
+	return s.StartTimer(namespace, name, time.Now())
+}
+
+// Stops running timer and shows tracked time
+func StopTimer(w io.Writer, s storage.TasksStorage) error {
+	stopped, err := s.StopTimer(time.Now())
+	if err != nil {
+		return err
+	}
+
+	if stopped == nil {
+		return fmt.Errorf("No running timer")
+	}
+
+	fmt.Fprintf(w, "Stopped '%s' after %s\n", strings.ReplaceAll(stopped.Name, PATH_SEPARATOR_REPLACER, "/"), formatDuration(stopped.Duration(time.Now())))
+	return nil
+}
+
+// Stops timer of tasks which are done, deleted or moved, so time isn't tracked on missing task
+func stopTimerByIndexes(namespace string, indexes []int, s storage.TasksStorage) error {
+	active, err := s.GetActiveTimer()
+	if err != nil || active == nil || active.Namespace != namespace {
+		return err
+	}
+
+	for _, index := range indexes {
+		name, err := s.GetNameByIndex(namespace, index)
+		if err != nil {
+			return err
+		}
+
+		if name == active.Name {
+			_, err = s.StopTimer(time.Now())
+			return err
+		}
+	}
+	return nil
+}
+
+func stopTimerOfNamespace(namespace string, s storage.TasksStorage) error {
+	active, err := s.GetActiveTimer()
+	if err != nil || active == nil || active.Namespace != namespace {
+		return err
+	}
+
+	_, err = s.StopTimer(time.Now())
+	return err
+}
+
+// Shows time tracked after since grouped by task, namespace or day, from all namespaces if namespace is empty.
+// Intervals started before since are counted from since, running interval is counted until now
+func ShowTimeReport(w io.Writer, namespace string, since time.Time, by string, s storage.TasksStorage) error {
+	intervals, err := s.GetIntervals(namespace, since)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	totals := map[string]time.Duration{}
+	running := map[string]bool{}
+	var total time.Duration
+
+	for _, interval := range intervals {
+		if interval.Start.Before(since) {
+			interval.Start = since
+		}
+
+		var group string
+		switch by {
+		case TIME_BY_TASK, "":
+			group = strings.ReplaceAll(interval.Name, PATH_SEPARATOR_REPLACER, "/")
+			if namespace == "" {
+				group = "[" + interval.Namespace + "] " + group
+			}
+		case TIME_BY_NAMESPACE:
+			group = interval.Namespace
+		case TIME_BY_DAY:
+			group = interval.Start.Format("2006-01-02 Mon")
+		default:
+			return fmt.Errorf("Unknown group '%s', expected %s", by, strings.Join(TIME_GROUPS, ", "))
+		}
+
+		duration := interval.Duration(now)
+		totals[group] += duration
+		total += duration
+		running[group] = running[group] || interval.End.IsZero()
+	}
+
+	groups := make([]string, 0, len(totals))
+	for group := range totals {
+		groups = append(groups, group)
+	}
+
+	// days in chronological order, other groups with most time first
+	sort.Slice(groups, func(i, j int) bool {
+		if by == TIME_BY_DAY || totals[groups[i]] == totals[groups[j]] {
+			return groups[i] < groups[j]
+		}
+		return totals[groups[i]] > totals[groups[j]]
+	})
+
+	if namespace != "" {
+		fmt.Fprintf(w, "%s\n", colorize("heading", "# "+namespace))
+	}
+
+	for _, group := range groups {
+		label := group
+		if running[group] {
+			label += colorize("timer", " [running]")
+		}
+		fmt.Fprintf(w, "%7s  %s\n", formatDuration(totals[group]), label)
+	}
+
+	fmt.Fprintf(w, "%7s  %s\n", formatDuration(total), "total")
+	return nil
+}
+
+// Formats duration as '1h05m' or '12m'
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+func formatTimer(started time.Time) string {
+	return fmt.Sprintf(" [tracking %s]", formatDuration(time.Since(started)))
+}
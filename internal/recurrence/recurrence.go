@@ -0,0 +1,193 @@
+package recurrence
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type kind int
+
+const (
+	daily kind = iota
+	weekly
+	monthly
+	everyNDays
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Day of monthly rule is day of month, 0 is day of due date
+type Rule struct {
+	kind    kind
+	weekday time.Weekday
+	days    int
+	day     int
+}
+
+// Parse accepts rules like "daily", "weekly mon", "monthly", "monthly 31", "every 3 days" or "every 3d"
+func Parse(s string) (Rule, error) {
+	fields := strings.Fields(strings.ToLower(s))
+	if len(fields) == 0 {
+		return Rule{}, fmt.Errorf("empty recurrence rule")
+	}
+
+	switch fields[0] {
+	case "daily":
+		if len(fields) != 1 {
+			break
+		}
+		return Rule{kind: daily}, nil
+
+	case "weekly":
+		if len(fields) != 2 {
+			break
+		}
+		weekday, err := parseWeekday(fields[1])
+		if err != nil {
+			return Rule{}, err
+		}
+		return Rule{kind: weekly, weekday: weekday}, nil
+
+	case "monthly":
+		if len(fields) == 1 {
+			return Rule{kind: monthly}, nil
+		}
+		if len(fields) != 2 {
+			break
+		}
+		day, err := strconv.Atoi(fields[1])
+		if err != nil || day < 1 || day > 31 {
+			return Rule{}, fmt.Errorf("wrong day of month '%s'", fields[1])
+		}
+		return Rule{kind: monthly, day: day}, nil
+
+	case "every":
+		days, err := parseDays(fields[1:])
+		if err != nil {
+			return Rule{}, err
+		}
+		return Rule{kind: everyNDays, days: days}, nil
+	}
+
+	return Rule{}, fmt.Errorf("unknown recurrence rule '%s', expected daily, weekly <weekday>, monthly [day] or every <N> days", s)
+}
+
+func parseWeekday(s string) (time.Weekday, error) {
+	if len(s) >= 3 {
+		if weekday, found := weekdays[s[:3]]; found {
+			return weekday, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown weekday '%s'", s)
+}
+
+func parseDays(fields []string) (int, error) {
+	var raw string
+	switch {
+	case len(fields) == 1 && strings.HasSuffix(fields[0], "d"):
+		raw = strings.TrimSuffix(fields[0], "d")
+	case len(fields) == 2 && (fields[1] == "days" || fields[1] == "day"):
+		raw = fields[0]
+	default:
+		return 0, fmt.Errorf("expected 'every <N> days'")
+	}
+
+	days, err := strconv.Atoi(raw)
+	if err != nil || days < 1 {
+		return 0, fmt.Errorf("wrong days count '%s'", raw)
+	}
+	return days, nil
+}
+
+func (r Rule) String() string {
+	switch r.kind {
+	case weekly:
+		return "weekly " + strings.ToLower(r.weekday.String()[:3])
+	case monthly:
+		if r.day != 0 {
+			return fmt.Sprintf("monthly %d", r.day)
+		}
+		return "monthly"
+	case everyNDays:
+		return fmt.Sprintf("every %d days", r.days)
+	}
+	return "daily"
+}
+
+// Anchored returns monthly rule without day with day of from,
+// so occurrence after shorter month returns to original day
+func (r Rule) Anchored(from time.Time) Rule {
+	if r.kind == monthly && r.day == 0 {
+		r.day = from.Day()
+	}
+	return r
+}
+
+// First returns the first occurrence on or after the day of from
+func (r Rule) First(from time.Time) time.Time {
+	day := truncateToDay(from)
+	switch r.kind {
+	case weekly:
+		return day.AddDate(0, 0, daysUntil(day.Weekday(), r.weekday))
+	case monthly:
+		if r.day == 0 {
+			return day
+		}
+		first := dayOfMonth(day.Year(), day.Month(), r.day, day.Location())
+		if first.Before(day) {
+			first = dayOfMonth(day.Year(), day.Month()+1, r.day, day.Location())
+		}
+		return first
+	}
+	return day
+}
+
+// Next returns the occurrence following the one due at due, skipping occurrences before now
+func (r Rule) Next(due time.Time, now time.Time) time.Time {
+	today := truncateToDay(now)
+	next := r.after(truncateToDay(due))
+	for next.Before(today) {
+		next = r.after(next)
+	}
+	return next
+}
+
+func (r Rule) after(day time.Time) time.Time {
+	switch r.kind {
+	case weekly:
+		return day.AddDate(0, 0, 1+daysUntil(day.AddDate(0, 0, 1).Weekday(), r.weekday))
+	case monthly:
+		monthDay := r.day
+		if monthDay == 0 {
+			monthDay = day.Day()
+		}
+		return dayOfMonth(day.Year(), day.Month()+1, monthDay, day.Location())
+	case everyNDays:
+		return day.AddDate(0, 0, r.days)
+	}
+	return day.AddDate(0, 0, 1)
+}
+
+// Returns day of month clamped to last day of month, so day 31 of February is February 28 or 29
+func dayOfMonth(year int, month time.Month, day int, loc *time.Location) time.Time {
+	lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+	return time.Date(year, month, min(day, lastDay), 0, 0, 0, 0, loc)
+}
+
+func daysUntil(from time.Weekday, to time.Weekday) int {
+	return (int(to) - int(from) + 7) % 7
+}
+
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
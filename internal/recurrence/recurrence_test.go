@@ -0,0 +1,132 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+)
+
+func day(s string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		rule     string
+		expected string
+	}{
+		{"daily", "daily"},
+		{"weekly Monday", "weekly mon"},
+		{"monthly", "monthly"},
+		{"monthly 31", "monthly 31"},
+		{"every 3 days", "every 3 days"},
+		{"every 3d", "every 3 days"},
+	}
+
+	for _, tt := range tests {
+		rule, err := Parse(tt.rule)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %s", tt.rule, err)
+			continue
+		}
+		if rule.String() != tt.expected {
+			t.Errorf("Parse(%q).String() = %q, expected %q", tt.rule, rule.String(), tt.expected)
+		}
+	}
+
+	for _, invalid := range []string{"", "weekly", "weekly xyz", "monthly 32", "monthly 0", "every 0 days", "yearly"} {
+		_, err := Parse(invalid)
+		if err == nil {
+			t.Errorf("Parse(%q) expected to fail", invalid)
+		}
+	}
+}
+
+func TestFirst(t *testing.T) {
+	tests := []struct {
+		rule     string
+		from     string
+		expected string
+	}{
+		{"daily", "2026-01-31", "2026-01-31"},
+		{"weekly mon", "2026-10-19", "2026-10-19"}, // monday
+		{"weekly fri", "2026-10-19", "2026-10-23"},
+		{"monthly", "2026-01-31", "2026-01-31"},
+		{"monthly 15", "2026-01-10", "2026-01-15"},
+		{"monthly 15", "2026-01-20", "2026-02-15"},
+		{"monthly 31", "2026-02-10", "2026-02-28"},
+		{"every 3 days", "2026-01-31", "2026-01-31"},
+	}
+
+	for _, tt := range tests {
+		rule, err := Parse(tt.rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		first := rule.First(day(tt.from))
+		if !first.Equal(day(tt.expected)) {
+			t.Errorf("%q.First(%s) = %s, expected %s", tt.rule, tt.from, first.Format("2006-01-02"), tt.expected)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		rule     string
+		due      string
+		now      string
+		expected string
+	}{
+		{"daily", "2026-01-31", "2026-01-31", "2026-02-01"},
+		{"daily", "2026-01-01", "2026-01-10", "2026-01-10"},
+		{"weekly mon", "2026-10-19", "2026-10-19", "2026-10-26"},
+		{"weekly fri", "2026-10-19", "2026-10-19", "2026-10-23"},
+		{"weekly mon", "2026-10-05", "2026-10-20", "2026-10-26"},
+		{"monthly 31", "2026-01-31", "2026-01-31", "2026-02-28"},
+		{"monthly 31", "2026-02-28", "2026-02-28", "2026-03-31"},
+		{"monthly 31", "2026-03-31", "2026-03-31", "2026-04-30"},
+		{"monthly 29", "2028-01-29", "2028-01-29", "2028-02-29"},
+		{"monthly 31", "2026-12-31", "2026-12-31", "2027-01-31"},
+		{"monthly 31", "2026-01-31", "2026-04-15", "2026-04-30"},
+		{"monthly", "2026-01-31", "2026-01-31", "2026-02-28"},
+		{"monthly", "2026-01-15", "2026-01-15", "2026-02-15"},
+		{"every 3 days", "2026-01-30", "2026-01-30", "2026-02-02"},
+		{"every 10 days", "2026-01-01", "2026-01-25", "2026-01-31"},
+	}
+
+	for _, tt := range tests {
+		rule, err := Parse(tt.rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		next := rule.Next(day(tt.due), day(tt.now))
+		if !next.Equal(day(tt.expected)) {
+			t.Errorf("%q.Next(%s, %s) = %s, expected %s", tt.rule, tt.due, tt.now, next.Format("2006-01-02"), tt.expected)
+		}
+	}
+}
+
+func TestAnchored(t *testing.T) {
+	rule, err := Parse("monthly")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	anchored := rule.Anchored(day("2026-01-31"))
+	if anchored.String() != "monthly 31" {
+		t.Errorf("Anchored() = %q, expected 'monthly 31'", anchored.String())
+	}
+
+	rule, err = Parse("monthly 15")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rule.Anchored(day("2026-01-31")).String() != "monthly 15" {
+		t.Errorf("Anchored() expected to keep day of rule")
+	}
+}
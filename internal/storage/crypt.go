@@ -0,0 +1,225 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// File in data directory with parameters of encryption, tasks are encrypted if it exists
+const ENCRYPTION_FILE = ".encryption"
+
+const ENCRYPTION_VERSION = 1
+
+// Iterations of PBKDF2, recommended by OWASP for HMAC-SHA256
+const DEFAULT_KDF_ITERATIONS = 600000
+
+const KEY_SIZE = 32
+const SALT_SIZE = 16
+
+// Known plaintext sealed by key, so wrong passphrase is detected before tasks are read
+const encryptionCheck = "t encryption check"
+
+// Parameters of encryption, key is derived from passphrase and Salt by PBKDF2 with HMAC-SHA256
+type EncryptionHeader struct {
+	Iterations int
+	Salt       []byte
+	Names      bool
+	Check      []byte
+}
+
+// Returns new header with random salt and key derived from passphrase
+func NewEncryptionHeader(passphrase string, names bool) (*EncryptionHeader, []byte, error) {
+	header := &EncryptionHeader{Iterations: DEFAULT_KDF_ITERATIONS, Salt: make([]byte, SALT_SIZE), Names: names}
+
+	_, err := rand.Read(header.Salt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key := header.derive(passphrase)
+	checkAEAD, err := newGCM(subkey(key, "check"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header.Check, err = seal(checkAEAD, []byte(encryptionCheck))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return header, key, nil
+}
+
+// Returns key of passphrase, error if passphrase is wrong
+func (h *EncryptionHeader) DeriveKey(passphrase string) ([]byte, error) {
+	key := h.derive(passphrase)
+	return key, h.VerifyKey(key)
+}
+
+func (h *EncryptionHeader) VerifyKey(key []byte) error {
+	checkAEAD, err := newGCM(subkey(key, "check"))
+	if err != nil {
+		return err
+	}
+
+	check, err := open(checkAEAD, h.Check)
+	if err != nil || string(check) != encryptionCheck {
+		return fmt.Errorf("Wrong passphrase")
+	}
+	return nil
+}
+
+// Identifies data directory without revealing its path, salt is random per data directory
+func (h *EncryptionHeader) ID() string {
+	sum := sha256.Sum256(h.Salt)
+	return hex.EncodeToString(sum[:8])
+}
+
+func (h *EncryptionHeader) derive(passphrase string) []byte {
+	return pbkdf2SHA256([]byte(passphrase), h.Salt, h.Iterations, KEY_SIZE)
+}
+
+// Returns header of data directory, nil if tasks are not encrypted
+func ReadEncryptionHeader(dir string) (*EncryptionHeader, error) {
+	content, err := os.ReadFile(path.Join(dir, ENCRYPTION_FILE))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	header := &EncryptionHeader{}
+	version := 0
+	for _, line := range strings.Split(string(content), "\n") {
+		key, value, found := strings.Cut(line, " ")
+		if !found {
+			continue
+		}
+
+		switch key {
+		case "version":
+			version, err = strconv.Atoi(value)
+		case "iterations":
+			header.Iterations, err = strconv.Atoi(value)
+		case "salt":
+			header.Salt, err = base64.StdEncoding.DecodeString(value)
+		case "names":
+			header.Names, err = strconv.ParseBool(value)
+		case "check":
+			header.Check, err = base64.StdEncoding.DecodeString(value)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("Error read %s: %s", ENCRYPTION_FILE, err)
+		}
+	}
+
+	if version != ENCRYPTION_VERSION {
+		return nil, fmt.Errorf("Error read %s: unsupported version %d", ENCRYPTION_FILE, version)
+	}
+
+	if header.Iterations < 1 || len(header.Salt) == 0 || len(header.Check) == 0 {
+		return nil, fmt.Errorf("Error read %s: missing parameters", ENCRYPTION_FILE)
+	}
+
+	return header, nil
+}
+
+func WriteEncryptionHeader(dir string, header *EncryptionHeader) error {
+	content := fmt.Sprintf("version %d\niterations %d\nsalt %s\nnames %t\ncheck %s\n",
+		ENCRYPTION_VERSION,
+		header.Iterations,
+		base64.StdEncoding.EncodeToString(header.Salt),
+		header.Names,
+		base64.StdEncoding.EncodeToString(header.Check),
+	)
+	return os.WriteFile(path.Join(dir, ENCRYPTION_FILE), []byte(content), 0644)
+}
+
+// PBKDF2 from RFC 8018 with HMAC-SHA256 as pseudorandom function
+func pbkdf2SHA256(password []byte, salt []byte, iterations int, keyLength int) []byte {
+	prf := hmac.New(sha256.New, password)
+	blocksCount := (keyLength + prf.Size() - 1) / prf.Size()
+
+	key := make([]byte, 0, blocksCount*prf.Size())
+	u := make([]byte, prf.Size())
+	for block := 1; block <= blocksCount; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(binary.BigEndian.AppendUint32(nil, uint32(block)))
+		u = prf.Sum(u[:0])
+
+		t := bytes.Clone(u)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+
+	return key[:keyLength]
+}
+
+// Derives independent key for purpose from master key
+func subkey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// Seals plaintext with random nonce, nonce is prefix of result
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Seals plaintext with nonce derived from plaintext like SIV mode, so equal plaintexts have equal results
+func sealDeterministic(aead cipher.AEAD, nonceKey []byte, plaintext []byte) []byte {
+	mac := hmac.New(sha256.New, nonceKey)
+	mac.Write(plaintext)
+	nonce := mac.Sum(nil)[:aead.NonceSize()]
+
+	return aead.Seal(nonce, nonce, plaintext, nil)
+}
+
+func open(aead cipher.AEAD, sealed []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("Encrypted data is too short")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
+
+// AES-256-GCM
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,480 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Prefix of encrypted content, content without it is read as plain text
+const ENCRYPTED_CONTENT_PREFIX = "t-encrypted:"
+
+// Longest name of task in bytes if names are encrypted. Encrypted name is base64 of nonce, name and tag,
+// with prefix of done log and suffix of MergeNamespace it must fit into file name of 255 bytes
+const MAX_ENCRYPTED_NAME_LENGTH = 140
+
+// Suffix added to name of task by MergeNamespace, it stays plain, so renamed task can be decrypted
+var renamedSuffix = regexp.MustCompile(` \([0-9]+\)$`)
+
+// Implemented by backends with sort orders of namespaces
+type namespaceSorter interface {
+	sortOrder(namespace string) SortOrder
+}
+
+// Wraps storage and encrypts contents of tasks and templates of recurrences by AES-256-GCM,
+// names are encrypted too if names is set. Encrypted name is the same for the same name,
+// so task is found by name. Namespaces, times, priorities and recurrence rules are plain.
+// Wrapper sorts tasks itself, so order by name and lines uses decrypted names and contents
+type EncryptedTasksStorage struct {
+	inner        TasksStorage
+	names        bool
+	content      cipher.AEAD
+	name         cipher.AEAD
+	nameNonceKey []byte
+}
+
+// Key is derived by EncryptionHeader from passphrase
+func NewEncryptedStorage(inner TasksStorage, key []byte, names bool) (*EncryptedTasksStorage, error) {
+	if len(key) != KEY_SIZE {
+		return nil, fmt.Errorf("Wrong size of encryption key: %d", len(key))
+	}
+
+	content, err := newGCM(subkey(key, "content"))
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := newGCM(subkey(key, "name"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &EncryptedTasksStorage{
+		inner:        inner,
+		names:        names,
+		content:      content,
+		name:         name,
+		nameNonceKey: subkey(key, "name nonce"),
+	}, nil
+}
+
+// Returns wrapped storage
+func (ts *EncryptedTasksStorage) Unwrap() TasksStorage {
+	return ts.inner
+}
+
+func (ts *EncryptedTasksStorage) GetNamespaces() ([]string, error) {
+	return ts.inner.GetNamespaces()
+}
+
+func (ts *EncryptedTasksStorage) Count(namespace string) (int, error) {
+	return ts.inner.Count(namespace)
+}
+
+// Pinned tasks are first, then manually ordered tasks, then not ordered tasks by sort order of namespace
+func (ts *EncryptedTasksStorage) GetSorted(namespace string) ([]string, error) {
+	order := SortOrder{By: SORT_UPDATED}
+	sorter, found := ts.inner.(namespaceSorter)
+	if found {
+		order = sorter.sortOrder(namespace)
+	}
+
+	items, infos, err := ts.sortItems(namespace, order)
+	if err != nil {
+		return nil, err
+	}
+
+	pins := map[string]time.Time{}
+	ranks := map[string]int{}
+	for name, info := range infos {
+		if !info.Pinned.IsZero() {
+			pins[name] = info.Pinned
+		}
+		if info.Rank != 0 {
+			ranks[name] = info.Rank
+		}
+	}
+
+	result := itemNames(items)
+	sortPinnedAndRanked(result, pins, ranks)
+	return result, nil
+}
+
+// Returns tasks sorted only by order, without pins and manual order
+func (ts *EncryptedTasksStorage) GetSortedBy(namespace string, order SortOrder) ([]string, error) {
+	items, _, err := ts.sortItems(namespace, order)
+	if err != nil {
+		return nil, err
+	}
+	return itemNames(items), nil
+}
+
+// Returns sorted items with decrypted names and infos by decrypted names
+func (ts *EncryptedTasksStorage) sortItems(namespace string, order SortOrder) ([]sortItem, map[string]TaskInfo, error) {
+	tasks, err := ts.inner.GetSortedBy(namespace, SortOrder{By: SORT_NAME})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	items := make([]sortItem, 0, len(tasks))
+	infos := make(map[string]TaskInfo, len(tasks))
+	for _, task := range tasks {
+		info, err := ts.inner.GetInfo(namespace, task)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		item := sortItem{
+			name:     ts.decryptName(task),
+			created:  info.Created,
+			updated:  info.Updated,
+			priority: info.Priority,
+		}
+
+		if order.By == SORT_LINES {
+			item.lines, err = ts.CountLines(namespace, item.name)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		items = append(items, item)
+		infos[item.name] = info
+	}
+
+	sortItems(items, order)
+	return items, infos, nil
+}
+
+func (ts *EncryptedTasksStorage) GetContentByIndex(namespace string, index int) ([]byte, error) {
+	name, err := ts.GetNameByIndex(namespace, index)
+	if err != nil {
+		return nil, err
+	}
+	return ts.GetContentByName(namespace, name)
+}
+
+func (ts *EncryptedTasksStorage) GetContentByName(namespace string, name string) ([]byte, error) {
+	content, err := ts.inner.GetContentByName(namespace, ts.encryptName(name))
+	if err != nil {
+		return nil, err
+	}
+	return ts.decryptContent(content)
+}
+
+func (ts *EncryptedTasksStorage) GetNameByIndex(namespace string, index int) (string, error) {
+	tasks, err := ts.GetSorted(namespace)
+	if err != nil {
+		return "", err
+	}
+
+	if index > len(tasks) || index < 1 {
+		return "", fmt.Errorf("Wrong task index: %d", index)
+	}
+
+	return tasks[index-1], nil
+}
+
+func (ts *EncryptedTasksStorage) DeleteByIndexes(namespace string, indexes []int) error {
+	innerIndexes, err := ts.innerIndexes(namespace, indexes)
+	if err != nil {
+		return err
+	}
+	return ts.inner.DeleteByIndexes(namespace, innerIndexes)
+}
+
+func (ts *EncryptedTasksStorage) CompleteByIndexes(namespace string, indexes []int) error {
+	innerIndexes, err := ts.innerIndexes(namespace, indexes)
+	if err != nil {
+		return err
+	}
+	return ts.inner.CompleteByIndexes(namespace, innerIndexes)
+}
+
+// Order of wrapper can differ from order of wrapped storage, so indexes are converted by names
+func (ts *EncryptedTasksStorage) innerIndexes(namespace string, indexes []int) ([]int, error) {
+	innerTasks, err := ts.inner.GetSorted(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make(map[string]int, len(innerTasks))
+	for i, task := range innerTasks {
+		positions[task] = i + 1
+	}
+
+	result := make([]int, 0, len(indexes))
+	for _, index := range indexes {
+		name, err := ts.GetNameByIndex(namespace, index)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, positions[ts.encryptName(name)])
+	}
+	return result, nil
+}
+
+func (ts *EncryptedTasksStorage) CompleteByName(namespace string, name string, doneAt time.Time) error {
+	return ts.inner.CompleteByName(namespace, ts.encryptName(name), doneAt)
+}
+
+func (ts *EncryptedTasksStorage) GetDone(namespace string, since time.Time) ([]DoneTask, error) {
+	doneTasks, err := ts.inner.GetDone(namespace, since)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range doneTasks {
+		doneTasks[i].Name = ts.decryptName(doneTasks[i].Name)
+	}
+	return doneTasks, nil
+}
+
+func (ts *EncryptedTasksStorage) WriteByName(namespace string, name string, r io.Reader) error {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := ts.encryptContent(content)
+	if err != nil {
+		return err
+	}
+
+	return ts.inner.WriteByName(namespace, ts.encryptName(name), bytes.NewReader(encrypted))
+}
+
+func (ts *EncryptedTasksStorage) WriteByIndex(namespace string, index int, r io.Reader) error {
+	name, err := ts.GetNameByIndex(namespace, index)
+	if err != nil {
+		return err
+	}
+	return ts.WriteByName(namespace, name, r)
+}
+
+func (ts *EncryptedTasksStorage) Add(namespace string, name string) error {
+	if ts.names && len(name) > MAX_ENCRYPTED_NAME_LENGTH {
+		return fmt.Errorf("Name of task is too long, maximum is %d bytes with encrypted names", MAX_ENCRYPTED_NAME_LENGTH)
+	}
+	return ts.inner.Add(namespace, ts.encryptName(name))
+}
+
+func (ts *EncryptedTasksStorage) CountLines(namespace string, name string) (int, error) {
+	content, err := ts.GetContentByName(namespace, name)
+	if err != nil {
+		return 0, err
+	}
+	return bytes.Count(content, []byte{'\n'}), nil
+}
+
+func (ts *EncryptedTasksStorage) GetInfo(namespace string, name string) (TaskInfo, error) {
+	info, err := ts.inner.GetInfo(namespace, ts.encryptName(name))
+	if err != nil {
+		return TaskInfo{}, err
+	}
+
+	content, err := ts.GetContentByName(namespace, name)
+	if err != nil {
+		return TaskInfo{}, err
+	}
+
+	info.Preview = preview(content)
+	return info, nil
+}
+
+func (ts *EncryptedTasksStorage) SetTimes(namespace string, name string, info TaskInfo) error {
+	return ts.inner.SetTimes(namespace, ts.encryptName(name), info)
+}
+
+func (ts *EncryptedTasksStorage) SetPriority(namespace string, name string, priority string) error {
+	return ts.inner.SetPriority(namespace, ts.encryptName(name), priority)
+}
+
+func (ts *EncryptedTasksStorage) SetPinned(namespace string, name string, pinned bool) error {
+	return ts.inner.SetPinned(namespace, ts.encryptName(name), pinned)
+}
+
+func (ts *EncryptedTasksStorage) SetRank(namespace string, name string, rank int) error {
+	return ts.inner.SetRank(namespace, ts.encryptName(name), rank)
+}
+
+func (ts *EncryptedTasksStorage) GetRecurrence(namespace string, name string) (*Recurrence, error) {
+	r, err := ts.inner.GetRecurrence(namespace, ts.encryptName(name))
+	if err != nil || r == nil {
+		return r, err
+	}
+
+	r.Template, err = ts.decryptContent(r.Template)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Template is content of task, so it is encrypted too
+func (ts *EncryptedTasksStorage) SetRecurrence(namespace string, name string, r *Recurrence) error {
+	if r != nil {
+		template, err := ts.encryptContent(r.Template)
+		if err != nil {
+			return err
+		}
+		r = &Recurrence{Rule: r.Rule, Due: r.Due, Template: template}
+	}
+
+	return ts.inner.SetRecurrence(namespace, ts.encryptName(name), r)
+}
+
+func (ts *EncryptedTasksStorage) RenameNamespace(old string, new string) error {
+	return ts.inner.RenameNamespace(old, new)
+}
+
+func (ts *EncryptedTasksStorage) MergeNamespace(src string, dst string) (map[string]string, error) {
+	renamed, err := ts.inner.MergeNamespace(src, dst)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string, len(renamed))
+	for name, newName := range renamed {
+		result[ts.decryptName(name)] = ts.decryptName(newName)
+	}
+	return result, nil
+}
+
+func (ts *EncryptedTasksStorage) DeleteNamespace(namespace string) error {
+	return ts.inner.DeleteNamespace(namespace)
+}
+
+func (ts *EncryptedTasksStorage) StartTimer(namespace string, name string, start time.Time) error {
+	return ts.inner.StartTimer(namespace, ts.encryptName(name), start)
+}
+
+func (ts *EncryptedTasksStorage) StopTimer(end time.Time) (*Interval, error) {
+	return ts.decryptInterval(ts.inner.StopTimer(end))
+}
+
+func (ts *EncryptedTasksStorage) GetActiveTimer() (*Interval, error) {
+	return ts.decryptInterval(ts.inner.GetActiveTimer())
+}
+
+func (ts *EncryptedTasksStorage) GetIntervals(namespace string, since time.Time) ([]Interval, error) {
+	intervals, err := ts.inner.GetIntervals(namespace, since)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range intervals {
+		intervals[i].Name = ts.decryptName(intervals[i].Name)
+	}
+	return intervals, nil
+}
+
+func (ts *EncryptedTasksStorage) decryptInterval(interval *Interval, err error) (*Interval, error) {
+	if err != nil || interval == nil {
+		return interval, err
+	}
+
+	interval.Name = ts.decryptName(interval.Name)
+	return interval, nil
+}
+
+// Deleted tasks have no content, so they have no preview
+func (ts *EncryptedTasksStorage) GetDeleted(namespace string) ([]DeletedTask, error) {
+	trash, keepsDeleted := ts.inner.(TrashStorage)
+	if !keepsDeleted {
+		return nil, fmt.Errorf("Deleted tasks are kept only by sqlite backend")
+	}
+
+	deletedTasks, err := trash.GetDeleted(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range deletedTasks {
+		deletedTasks[i].Name = ts.decryptName(deletedTasks[i].Name)
+		if strings.HasPrefix(deletedTasks[i].Info.Preview, ENCRYPTED_CONTENT_PREFIX) {
+			deletedTasks[i].Info.Preview = ""
+		}
+	}
+	return deletedTasks, nil
+}
+
+// Empty content stays empty, so new task has no ciphertext of nothing
+func (ts *EncryptedTasksStorage) encryptContent(content []byte) ([]byte, error) {
+	if len(content) == 0 {
+		return content, nil
+	}
+
+	sealed, err := seal(ts.content, content)
+	if err != nil {
+		return nil, err
+	}
+
+	// text without newlines, so backends store and count it as one line of text
+	return []byte(ENCRYPTED_CONTENT_PREFIX + base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+func (ts *EncryptedTasksStorage) decryptContent(content []byte) ([]byte, error) {
+	encoded, found := bytes.CutPrefix(content, []byte(ENCRYPTED_CONTENT_PREFIX))
+	if !found {
+		return content, nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(string(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("Error decrypt task: %s", err)
+	}
+
+	plaintext, err := open(ts.content, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("Error decrypt task: %s", err)
+	}
+	return plaintext, nil
+}
+
+// Encrypted name is url safe base64, so it is valid file name
+func (ts *EncryptedTasksStorage) encryptName(name string) string {
+	if !ts.names {
+		return name
+	}
+
+	suffix := renamedSuffix.FindString(name)
+	sealed := sealDeterministic(ts.name, ts.nameNonceKey, []byte(strings.TrimSuffix(name, suffix)))
+	return base64.RawURLEncoding.EncodeToString(sealed) + suffix
+}
+
+// Name which can't be decrypted is returned as is, so tasks added before encryption stay readable
+func (ts *EncryptedTasksStorage) decryptName(name string) string {
+	if !ts.names {
+		return name
+	}
+
+	suffix := renamedSuffix.FindString(name)
+	sealed, err := base64.RawURLEncoding.DecodeString(strings.TrimSuffix(name, suffix))
+	if err != nil {
+		return name
+	}
+
+	plaintext, err := open(ts.name, sealed)
+	if err != nil {
+		return name
+	}
+	return string(plaintext) + suffix
+}
+
+// Returns storage keeping deleted tasks, false if backend doesn't keep them
+func AsTrash(s TasksStorage) (TrashStorage, bool) {
+	encrypted, isEncrypted := s.(*EncryptedTasksStorage)
+	if isEncrypted {
+		_, keepsDeleted := encrypted.inner.(TrashStorage)
+		return encrypted, keepsDeleted
+	}
+
+	trash, keepsDeleted := s.(TrashStorage)
+	return trash, keepsDeleted
+}
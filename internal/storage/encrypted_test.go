@@ -0,0 +1,170 @@
+package storage_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	storage "github.com/thek4n/t/internal/storage"
+	"github.com/thek4n/t/internal/storage/storagetest"
+)
+
+var testKey = bytes.Repeat([]byte{7}, storage.KEY_SIZE)
+
+func newEncrypted(t *testing.T, inner storage.TasksStorage, names bool) storage.TasksStorage {
+	s, err := storage.NewEncryptedStorage(inner, testKey, names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestEncryptedTasksStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T, sortBy string) storage.TasksStorage {
+		return newEncrypted(t, &storage.MemoryTasksStorage{Sort: sortBy}, false)
+	})
+}
+
+func TestEncryptedNamesTasksStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T, sortBy string) storage.TasksStorage {
+		return newEncrypted(t, &storage.FSTasksStorage{TBaseDir: t.TempDir(), Sort: sortBy}, true)
+	})
+}
+
+func TestEncryptedFilesArePrivate(t *testing.T) {
+	dir := t.TempDir()
+	s := newEncrypted(t, &storage.FSTasksStorage{TBaseDir: dir, Sort: storage.SORT_NAME}, true)
+
+	err := s.Add("def", "customer acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.WriteByName("def", "customer acme", strings.NewReader("password hunter2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(path.Join(dir, "def"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || strings.Contains(entries[0].Name(), "acme") {
+		t.Fatalf("expected one file with encrypted name, got %v", entries)
+	}
+
+	raw, err := os.ReadFile(path.Join(dir, "def", entries[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(raw, []byte("hunter2")) || !bytes.HasPrefix(raw, []byte(storage.ENCRYPTED_CONTENT_PREFIX)) {
+		t.Errorf("expected encrypted content, got %q", raw)
+	}
+
+	other := newEncrypted(t, &storage.FSTasksStorage{TBaseDir: dir}, true)
+	_, err = other.GetContentByIndex("def", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wrongKey, err := storage.NewEncryptedStorage(&storage.FSTasksStorage{TBaseDir: dir}, bytes.Repeat([]byte{8}, storage.KEY_SIZE), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = wrongKey.GetContentByIndex("def", 1)
+	if err == nil {
+		t.Errorf("expected error reading content by wrong key")
+	}
+}
+
+func TestEncryptionHeader(t *testing.T) {
+	dir := t.TempDir()
+
+	header, err := storage.ReadEncryptionHeader(dir)
+	if err != nil || header != nil {
+		t.Fatalf("ReadEncryptionHeader() of plain directory = %+v, %v, expected nil", header, err)
+	}
+
+	header, key, err := storage.NewEncryptionHeader("correct horse", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = storage.WriteEncryptionHeader(dir, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := storage.ReadEncryptionHeader(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !read.Names || read.ID() != header.ID() {
+		t.Errorf("ReadEncryptionHeader() = %+v, expected %+v", read, header)
+	}
+
+	derived, err := read.DeriveKey("correct horse")
+	if err != nil || !bytes.Equal(derived, key) {
+		t.Errorf("DeriveKey() = %x, %v, expected %x", derived, err, key)
+	}
+
+	_, err = read.DeriveKey("wrong horse")
+	if err == nil {
+		t.Errorf("DeriveKey() of wrong passphrase expected to fail")
+	}
+}
+
+// Encrypted name with prefix of done log must fit into file name
+func TestEncryptedNameLength(t *testing.T) {
+	s := newEncrypted(t, &storage.FSTasksStorage{TBaseDir: t.TempDir(), Sort: storage.SORT_NAME}, true)
+
+	longest := strings.Repeat("x", storage.MAX_ENCRYPTED_NAME_LENGTH)
+	err := s.Add("def", longest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.CompleteByName("def", longest, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done, err := s.GetDone("def", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 1 || done[0].Name != longest {
+		t.Errorf("GetDone() = %+v, expected task with longest name", done)
+	}
+
+	err = s.Add("def", longest+"x")
+	if err == nil {
+		t.Errorf("Add() of name longer than %d bytes expected to fail", storage.MAX_ENCRYPTED_NAME_LENGTH)
+	}
+}
+
+// Test vectors of PBKDF2-HMAC-SHA256 from RFC 7914, key of two blocks
+func TestPBKDF2SHA256(t *testing.T) {
+	tests := []struct {
+		password   string
+		salt       string
+		iterations int
+		expected   string
+	}{
+		{"passwd", "salt", 1, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc" +
+			"49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+		{"Password", "NaCl", 80000, "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56" +
+			"a1d425a1225833549adb841b51c9b3176a272bdebba1d078478f62b397f33c8d"},
+	}
+
+	for _, tt := range tests {
+		key := storage.PBKDF2SHA256([]byte(tt.password), []byte(tt.salt), tt.iterations, 64)
+		if hex.EncodeToString(key) != tt.expected {
+			t.Errorf("pbkdf2SHA256(%q, %q, %d) = %x, expected %s", tt.password, tt.salt, tt.iterations, key, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,4 @@
+package storage
+
+// Unexported functions used by tests of package storage_test
+var PBKDF2SHA256 = pbkdf2SHA256
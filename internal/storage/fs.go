@@ -1,89 +1,155 @@
-//go:build !tsqlite
-
 package storage
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path"
-	"sort"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const PATH_SEPARATOR_REPLACER = "%2F"
+const RECURRENCES_DIR = ".recur"
+const DONE_DIR = ".done"
+const PRIORITIES_DIR = ".priority"
+const PINS_DIR = ".pinned"
+const RANKS_DIR = ".rank"
+const TIME_DIR = ".time"
+
+// Running timer is file in time directory, hidden name can't be namespace
+const ACTIVE_TIMER_FILE = ".active"
+
+// Directories with metadata of tasks in layout '<DIR>/<NAMESPACE>/<TASK>'
+var METADATA_DIRS = []string{RECURRENCES_DIR, PRIORITIES_DIR, PINS_DIR, RANKS_DIR}
 
 type FSTasksStorage struct {
-	TBaseDir string
+	TBaseDir       string
+	Sort           string
+	Reverse        bool
+	NamespaceSorts map[string]SortOrder
 }
 
+// Namespaces are directories, nested directories are child namespaces like 'work/backend'
 func (ts *FSTasksStorage) GetNamespaces() ([]string, error) {
-	dirEntries, err := os.ReadDir(ts.TBaseDir)
-	if err != nil {
-		return nil, err
-	}
+	return listNamespaceDirs(ts.TBaseDir)
+}
 
-	result := make([]string, 0, len(dirEntries))
-	for _, de := range dirEntries {
-		if !de.IsDir() {
-			continue
+// Returns paths of directories relative to root, skipping hidden ones
+func listNamespaceDirs(root string) ([]string, error) {
+	result := []string{}
+
+	err := filepath.WalkDir(root, func(p string, de os.DirEntry, err error) error {
+		if err != nil {
+			return err
 		}
+
+		if !de.IsDir() || p == root {
+			return nil
+		}
+
 		if de.Name()[0] == '.' {
-			continue
+			return filepath.SkipDir
 		}
 
-		result = append(result, de.Name())
-	}
-	return result, nil
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+
+		result = append(result, filepath.ToSlash(rel))
+		return nil
+	})
+
+	return result, err
 }
 
 func (ts *FSTasksStorage) Count(namespace string) (int, error) {
-	namespaceDirEntries, err := os.ReadDir(path.Join(ts.TBaseDir, namespace))
+	tasks, err := ts.readTasks(namespace)
 	if err != nil {
 		return 0, err
 	}
-	return len(namespaceDirEntries), nil
+	return len(tasks), nil
 }
 
+// Pinned tasks are first, then manually ordered tasks, then not ordered tasks by sort order of namespace
 func (ts *FSTasksStorage) GetSorted(namespace string) ([]string, error) {
-	namespacePath := path.Join(ts.TBaseDir, namespace)
-	dirEntries, err := os.ReadDir(namespacePath)
+	result, err := ts.GetSortedBy(namespace, ts.sortOrder(namespace))
 	if err != nil {
 		return nil, err
 	}
 
-	sortErr := sortTasks(dirEntries)
-	if sortErr != nil {
-		return nil, fmt.Errorf("Error sorting tasks: %s", sortErr)
+	pins, err := ts.getPins(namespace)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading pins: %s", err)
 	}
 
-	result := make([]string, len(dirEntries))
-	for i, de := range dirEntries {
-		if de.IsDir() {
-			continue
-		}
-
-		result[i] = de.Name()
+	ranks, err := ts.getRanks(namespace)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading ranks: %s", err)
 	}
+	sortPinnedAndRanked(result, pins, ranks)
 
 	return result, nil
 }
 
-func sortTasks(tasks []os.DirEntry) error {
-	var sortErr error
+// Own order of namespace or default order
+func (ts *FSTasksStorage) sortOrder(namespace string) SortOrder {
+	return namespaceOrder(namespace, ts.Sort, ts.Reverse, ts.NamespaceSorts)
+}
+
+// Returns tasks sorted only by order, without pins and manual order.
+// Files have only modification time, so it is used as created time too
+func (ts *FSTasksStorage) GetSortedBy(namespace string, order SortOrder) ([]string, error) {
+	tasks, err := ts.readTasks(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]sortItem, 0, len(tasks))
+	for _, de := range tasks {
+		info, err := de.Info()
+		if err != nil {
+			return nil, fmt.Errorf("Error sorting tasks: %s", err)
+		}
 
-	sort.Slice(tasks, func(i, j int) bool {
-		iInfo, err := tasks[i].Info()
-		jInfo, err := tasks[j].Info()
+		item := sortItem{name: de.Name(), created: info.ModTime(), updated: info.ModTime()}
 
+		switch order.By {
+		case SORT_LINES:
+			item.lines, err = countFileLines(path.Join(ts.TBaseDir, namespace, de.Name()))
+		case SORT_PRIORITY:
+			item.priority, err = ts.getPriority(namespace, de.Name())
+		}
 		if err != nil {
-			sortErr = err
+			return nil, fmt.Errorf("Error sorting tasks: %s", err)
 		}
 
-		return iInfo.ModTime().Unix() > jInfo.ModTime().Unix()
-	})
-	return sortErr
+		items = append(items, item)
+	}
+
+	sortItems(items, order)
+	return itemNames(items), nil
+}
+
+// Returns task files of namespace, directories of child namespaces are skipped
+func (ts *FSTasksStorage) readTasks(namespace string) ([]os.DirEntry, error) {
+	dirEntries, err := os.ReadDir(path.Join(ts.TBaseDir, namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]os.DirEntry, 0, len(dirEntries))
+	for _, de := range dirEntries {
+		if !de.IsDir() {
+			tasks = append(tasks, de)
+		}
+	}
+	return tasks, nil
 }
 
 func (ts *FSTasksStorage) GetContentByIndex(namespace string, index int) ([]byte, error) {
@@ -106,7 +172,7 @@ func (ts *FSTasksStorage) GetContentByIndex(namespace string, index int) ([]byte
 }
 
 func (ts *FSTasksStorage) GetContentByName(namespace string, name string) ([]byte, error) {
-	content, err := os.ReadFile(path.Join(ts.TBaseDir, namespace, name))
+	content, err := os.ReadFile(ts.taskPath(namespace, name))
 	if err != nil {
 		return nil, fmt.Errorf("Error reading file: %w", err)
 	}
@@ -115,74 +181,1089 @@ func (ts *FSTasksStorage) GetContentByName(namespace string, name string) ([]byt
 }
 
 func (ts *FSTasksStorage) DeleteByIndexes(namespace string, indexes []int) error {
-	tasks, err := ts.GetSorted(namespace)
+	names, err := ts.getNamesByIndexes(namespace, indexes)
 	if err != nil {
 		return err
 	}
 
-	for _, inputedTaskIndex := range indexes {
-		if inputedTaskIndex > len(tasks) || inputedTaskIndex < 1 {
-			return fmt.Errorf("Wrong task index: %d", inputedTaskIndex)
-		}
-
-		taskNameToDelete := tasks[inputedTaskIndex-1]
+	for _, taskNameToDelete := range names {
 		deleteErr := os.Remove(path.Join(ts.TBaseDir, namespace, taskNameToDelete))
 		if deleteErr != nil {
 			return fmt.Errorf("Error remove file: %s", deleteErr)
 		}
+
+		err = ts.removeMetadata(namespace, taskNameToDelete)
+		if err != nil {
+			return fmt.Errorf("Error removing metadata: %s", err)
+		}
 	}
 
 	return nil
 }
 
-func (ts *FSTasksStorage) Add(namespace string, name string) error {
+func (ts *FSTasksStorage) CompleteByIndexes(namespace string, indexes []int) error {
+	names, err := ts.getNamesByIndexes(namespace, indexes)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		err = ts.CompleteByName(namespace, name, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Validates all indexes before returning names, so invalid index doesn't cause partial change
+func (ts *FSTasksStorage) getNamesByIndexes(namespace string, indexes []int) ([]string, error) {
+	tasks, err := ts.GetSorted(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(indexes))
+	for _, index := range indexes {
+		if index > len(tasks) || index < 1 {
+			return nil, fmt.Errorf("Wrong task index: %d", index)
+		}
+		names = append(names, tasks[index-1])
+	}
+	return names, nil
+}
+
+func (ts *FSTasksStorage) CompleteByName(namespace string, name string, doneAt time.Time) error {
 	name = strings.ReplaceAll(name, "/", PATH_SEPARATOR_REPLACER)
 
-	err := os.WriteFile(path.Join(ts.TBaseDir, namespace, name), []byte{}, 0644)
+	recurrence, err := ts.GetRecurrence(namespace, name)
 	if err != nil {
-		return fmt.Errorf("Error write file: %s", err)
+		return fmt.Errorf("Error reading recurrence: %s", err)
+	}
+
+	err = ts.moveToDone(namespace, name, doneAt)
+	if err != nil {
+		return fmt.Errorf("Error moving task to done: %s", err)
+	}
+
+	err = ts.removeMetadata(namespace, name)
+	if err != nil {
+		return fmt.Errorf("Error removing metadata: %s", err)
+	}
+
+	if recurrence != nil {
+		err = ts.recur(namespace, name, recurrence)
+		if err != nil {
+			return fmt.Errorf("Error creating next occurrence: %s", err)
+		}
 	}
 
 	return nil
 }
 
-func (ts *FSTasksStorage) WriteByName(namespace string, name string, r io.Reader) error {
-	taskToEdit := path.Join(ts.TBaseDir, namespace, name)
+func (ts *FSTasksStorage) removeMetadata(namespace string, name string) error {
+	for _, metadataDir := range METADATA_DIRS {
+		err := os.Remove(path.Join(ts.TBaseDir, metadataDir, namespace, name))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
 
-	file, err := os.Create(taskToEdit)
+func (ts *FSTasksStorage) SetPriority(namespace string, name string, priority string) error {
+	name = strings.ReplaceAll(name, "/", PATH_SEPARATOR_REPLACER)
+	priorityPath := path.Join(ts.TBaseDir, PRIORITIES_DIR, namespace, name)
+
+	if priority == "" {
+		err := os.Remove(priorityPath)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	err := os.MkdirAll(path.Dir(priorityPath), 0755)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	_, err = io.Copy(file, r)
-	return err
+	return os.WriteFile(priorityPath, []byte(priority+"\n"), 0644)
 }
 
-func (ts *FSTasksStorage) WriteByIndex(namespace string, index int, r io.Reader) error {
-	taskNameToEdit, err := ts.GetNameByIndex(namespace, index)
+func (ts *FSTasksStorage) getPriority(namespace string, name string) (string, error) {
+	content, err := os.ReadFile(path.Join(ts.TBaseDir, PRIORITIES_DIR, namespace, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	return strings.TrimSpace(string(content)), err
+}
+
+// Pin is file with time of pinning, so pinning doesn't change modification time of task.
+// Pinning already pinned task keeps its place among pinned tasks
+func (ts *FSTasksStorage) SetPinned(namespace string, name string, pinned bool) error {
+	name = strings.ReplaceAll(name, "/", PATH_SEPARATOR_REPLACER)
+	pinPath := path.Join(ts.TBaseDir, PINS_DIR, namespace, name)
+
+	if !pinned {
+		err := os.Remove(pinPath)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	if exists(pinPath) {
+		return nil
+	}
+
+	err := os.MkdirAll(path.Dir(pinPath), 0755)
 	if err != nil {
 		return err
 	}
 
-	return ts.WriteByName(namespace, taskNameToEdit, r)
+	return os.WriteFile(pinPath, []byte(time.Now().Format(time.RFC3339Nano)+"\n"), 0644)
 }
 
-func (ts *FSTasksStorage) GetNameByIndex(namespace string, index int) (string, error) {
-	tasks, err := ts.GetSorted(namespace)
+// Returns times of pinning by names of pinned tasks
+func (ts *FSTasksStorage) getPins(namespace string) (map[string]time.Time, error) {
+	pins := map[string]time.Time{}
+
+	entries, err := os.ReadDir(path.Join(ts.TBaseDir, PINS_DIR, namespace))
+	if errors.Is(err, os.ErrNotExist) {
+		return pins, nil
+	}
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	if index > len(tasks) || index < 1 {
-		return "", fmt.Errorf("Wrong task index")
+	for _, de := range entries {
+		if de.IsDir() {
+			continue
+		}
+
+		pinnedAt, err := ts.getPin(namespace, de.Name())
+		if err != nil {
+			return nil, err
+		}
+		pins[de.Name()] = pinnedAt
 	}
+	return pins, nil
+}
 
-	return tasks[index-1], nil
+func (ts *FSTasksStorage) getPin(namespace string, name string) (time.Time, error) {
+	content, err := os.ReadFile(path.Join(ts.TBaseDir, PINS_DIR, namespace, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Parse(time.RFC3339Nano, strings.TrimSpace(string(content)))
 }
 
-func (ts *FSTasksStorage) CountLines(namespace string, name string) (int, error) {
-	return countFileLines(path.Join(ts.TBaseDir, namespace, name))
+// Rank is file with number, so manual order doesn't change modification time of task.
+// Rank 0 removes task from manual order
+func (ts *FSTasksStorage) SetRank(namespace string, name string, rank int) error {
+	name = strings.ReplaceAll(name, "/", PATH_SEPARATOR_REPLACER)
+	rankPath := path.Join(ts.TBaseDir, RANKS_DIR, namespace, name)
+
+	if rank == 0 {
+		err := os.Remove(rankPath)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	err := os.MkdirAll(path.Dir(rankPath), 0755)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(rankPath, []byte(strconv.Itoa(rank)+"\n"), 0644)
+}
+
+// Returns ranks by names of manually ordered tasks
+func (ts *FSTasksStorage) getRanks(namespace string) (map[string]int, error) {
+	ranks := map[string]int{}
+
+	entries, err := os.ReadDir(path.Join(ts.TBaseDir, RANKS_DIR, namespace))
+	if errors.Is(err, os.ErrNotExist) {
+		return ranks, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, de := range entries {
+		if de.IsDir() {
+			continue
+		}
+
+		rank, err := ts.getRank(namespace, de.Name())
+		if err != nil {
+			return nil, err
+		}
+		ranks[de.Name()] = rank
+	}
+	return ranks, nil
+}
+
+func (ts *FSTasksStorage) getRank(namespace string, name string) (int, error) {
+	content, err := os.ReadFile(path.Join(ts.TBaseDir, RANKS_DIR, namespace, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(strings.TrimSpace(string(content)))
+}
+
+// Files have only modification time, so it is set to updated time or to created time
+func (ts *FSTasksStorage) SetTimes(namespace string, name string, info TaskInfo) error {
+	name = strings.ReplaceAll(name, "/", PATH_SEPARATOR_REPLACER)
+
+	mtime := info.Updated
+	if mtime.IsZero() {
+		mtime = info.Created
+	}
+	if mtime.IsZero() {
+		return nil
+	}
+
+	return os.Chtimes(path.Join(ts.TBaseDir, namespace, name), time.Time{}, mtime)
+}
+
+// Done task keeps its content in file named '<UNIX NANO>_<NAME>' under done directory
+func (ts *FSTasksStorage) moveToDone(namespace string, name string, doneAt time.Time) error {
+	doneNamespacePath := path.Join(ts.TBaseDir, DONE_DIR, namespace)
+
+	err := os.MkdirAll(doneNamespacePath, 0755)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(
+		path.Join(ts.TBaseDir, namespace, name),
+		path.Join(doneNamespacePath, fmt.Sprintf("%d_%s", doneAt.UnixNano(), name)),
+	)
+}
+
+func (ts *FSTasksStorage) GetDone(namespace string, since time.Time) ([]DoneTask, error) {
+	namespaces := []string{namespace}
+	if namespace == "" {
+		var err error
+		namespaces, err = listNamespaceDirs(path.Join(ts.TBaseDir, DONE_DIR))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := []DoneTask{}
+	for _, ns := range namespaces {
+		dirEntries, err := os.ReadDir(path.Join(ts.TBaseDir, DONE_DIR, ns))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, de := range dirEntries {
+			if de.IsDir() {
+				continue
+			}
+
+			rawDoneAt, name, found := strings.Cut(de.Name(), "_")
+			if !found {
+				continue
+			}
+
+			doneAtNano, err := strconv.ParseInt(rawDoneAt, 10, 64)
+			if err != nil {
+				continue
+			}
+
+			doneAt := time.Unix(0, doneAtNano)
+			if doneAt.Before(since) {
+				continue
+			}
+
+			info, err := de.Info()
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, DoneTask{Namespace: ns, Name: name, Created: info.ModTime(), DoneAt: doneAt})
+		}
+	}
+
+	sortDone(result)
+	return result, nil
+}
+
+// Time log of task is file '<TIME_DIR>/<NAMESPACE>/<TASK>' with line '<START UNIX NANO> <END UNIX NANO>' per interval,
+// it is kept after task is done like done log
+func (ts *FSTasksStorage) StartTimer(namespace string, name string, start time.Time) error {
+	name = strings.ReplaceAll(name, "/", PATH_SEPARATOR_REPLACER)
+	if !exists(path.Join(ts.TBaseDir, namespace, name)) {
+		return fmt.Errorf("Task '%s' not found", strings.ReplaceAll(name, PATH_SEPARATOR_REPLACER, "/"))
+	}
+
+	_, err := ts.StopTimer(start)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(path.Join(ts.TBaseDir, TIME_DIR), 0755)
+	if err != nil {
+		return err
+	}
+
+	active := fmt.Sprintf("%d\n%s\n%s\n", start.UnixNano(), namespace, name)
+	return os.WriteFile(ts.activeTimerPath(), []byte(active), 0644)
+}
+
+func (ts *FSTasksStorage) StopTimer(end time.Time) (*Interval, error) {
+	active, err := ts.GetActiveTimer()
+	if err != nil || active == nil {
+		return nil, err
+	}
+	active.End = end
+
+	timeLogPath := path.Join(ts.TBaseDir, TIME_DIR, active.Namespace, active.Name)
+	err = os.MkdirAll(path.Dir(timeLogPath), 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(timeLogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = fmt.Fprintf(f, "%d %d\n", active.Start.UnixNano(), active.End.UnixNano())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return active, os.Remove(ts.activeTimerPath())
+}
+
+func (ts *FSTasksStorage) GetActiveTimer() (*Interval, error) {
+	content, err := os.ReadFile(ts.activeTimerPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	if len(lines) != 3 {
+		return nil, fmt.Errorf("Error read active timer: invalid file '%s'", ts.activeTimerPath())
+	}
+
+	startNano, err := strconv.ParseInt(lines[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("Error read active timer: %s", err)
+	}
+
+	return &Interval{Namespace: lines[1], Name: lines[2], Start: time.Unix(0, startNano)}, nil
+}
+
+func (ts *FSTasksStorage) GetIntervals(namespace string, since time.Time) ([]Interval, error) {
+	timeRoot := path.Join(ts.TBaseDir, TIME_DIR)
+
+	namespaces := []string{namespace}
+	if namespace == "" {
+		var err error
+		namespaces, err = listNamespaceDirs(timeRoot)
+		if errors.Is(err, os.ErrNotExist) {
+			namespaces = nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	result := []Interval{}
+	for _, ns := range namespaces {
+		dirEntries, err := os.ReadDir(path.Join(timeRoot, ns))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, de := range dirEntries {
+			if de.IsDir() {
+				continue
+			}
+
+			intervals, err := readTimeLog(path.Join(timeRoot, ns, de.Name()))
+			if err != nil {
+				return nil, err
+			}
+
+			for _, interval := range intervals {
+				if interval.End.Before(since) {
+					continue
+				}
+
+				interval.Namespace = ns
+				interval.Name = de.Name()
+				result = append(result, interval)
+			}
+		}
+	}
+
+	active, err := ts.GetActiveTimer()
+	if err != nil {
+		return nil, err
+	}
+	if active != nil && (namespace == "" || active.Namespace == namespace) {
+		result = append(result, *active)
+	}
+
+	sortIntervals(result)
+	return result, nil
+}
+
+func readTimeLog(timeLogPath string) ([]Interval, error) {
+	content, err := os.ReadFile(timeLogPath)
+	if err != nil {
+		return nil, err
+	}
+
+	intervals := []Interval{}
+	for _, line := range strings.Split(string(content), "\n") {
+		rawStart, rawEnd, found := strings.Cut(line, " ")
+		if !found {
+			continue
+		}
+
+		startNano, err := strconv.ParseInt(rawStart, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		endNano, err := strconv.ParseInt(rawEnd, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		intervals = append(intervals, Interval{Start: time.Unix(0, startNano), End: time.Unix(0, endNano)})
+	}
+	return intervals, nil
+}
+
+func (ts *FSTasksStorage) activeTimerPath() string {
+	return path.Join(ts.TBaseDir, TIME_DIR, ACTIVE_TIMER_FILE)
+}
+
+// Running timer follows its task when namespace is renamed or merged
+func (ts *FSTasksStorage) moveActiveTimer(move func(namespace string, name string) (string, string, bool)) error {
+	active, err := ts.GetActiveTimer()
+	if err != nil || active == nil {
+		return err
+	}
+
+	namespace, name, moved := move(active.Namespace, active.Name)
+	if !moved {
+		return nil
+	}
+
+	content := fmt.Sprintf("%d\n%s\n%s\n", active.Start.UnixNano(), namespace, name)
+	return os.WriteFile(ts.activeTimerPath(), []byte(content), 0644)
+}
+
+func (ts *FSTasksStorage) recur(namespace string, name string, r *Recurrence) error {
+	next, err := nextRecurrence(r, time.Now())
+	if err != nil {
+		return err
+	}
+
+	err = ts.WriteByName(namespace, name, bytes.NewReader(next.Template))
+	if err != nil {
+		return err
+	}
+
+	return ts.SetRecurrence(namespace, name, next)
+}
+
+func (ts *FSTasksStorage) GetRecurrence(namespace string, name string) (*Recurrence, error) {
+	content, err := os.ReadFile(ts.recurrencePath(namespace, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalRecurrence(content)
+}
+
+func (ts *FSTasksStorage) SetRecurrence(namespace string, name string, r *Recurrence) error {
+	recurrencePath := ts.recurrencePath(namespace, name)
+
+	if r == nil {
+		err := os.Remove(recurrencePath)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	err := os.MkdirAll(path.Dir(recurrencePath), 0755)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(recurrencePath, marshalRecurrence(r), 0644)
+}
+
+func (ts *FSTasksStorage) recurrencePath(namespace string, name string) string {
+	return path.Join(ts.TBaseDir, RECURRENCES_DIR, namespace, strings.ReplaceAll(name, "/", PATH_SEPARATOR_REPLACER))
+}
+
+// Name of task is encoded, so name returned by GetSorted and human readable name are the same task
+func (ts *FSTasksStorage) taskPath(namespace string, name string) string {
+	return path.Join(ts.TBaseDir, namespace, strings.ReplaceAll(name, "/", PATH_SEPARATOR_REPLACER))
+}
+
+func (ts *FSTasksStorage) Add(namespace string, name string) error {
+	name = strings.ReplaceAll(name, "/", PATH_SEPARATOR_REPLACER)
+
+	err := os.MkdirAll(path.Join(ts.TBaseDir, namespace), 0755)
+	if err != nil {
+		return fmt.Errorf("Error create namespace directory: %s", err)
+	}
+
+	file, err := os.OpenFile(path.Join(ts.TBaseDir, namespace, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("Task '%s' already exists", strings.ReplaceAll(name, PATH_SEPARATOR_REPLACER, "/"))
+	}
+	if err != nil {
+		return fmt.Errorf("Error write file: %s", err)
+	}
+
+	return file.Close()
+}
+
+func (ts *FSTasksStorage) WriteByName(namespace string, name string, r io.Reader) error {
+	name = strings.ReplaceAll(name, "/", PATH_SEPARATOR_REPLACER)
+	taskToEdit := path.Join(ts.TBaseDir, namespace, name)
+
+	file, err := os.Create(taskToEdit)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(file, r)
+	return err
+}
+
+func (ts *FSTasksStorage) WriteByIndex(namespace string, index int, r io.Reader) error {
+	taskNameToEdit, err := ts.GetNameByIndex(namespace, index)
+	if err != nil {
+		return err
+	}
+
+	return ts.WriteByName(namespace, taskNameToEdit, r)
+}
+
+func (ts *FSTasksStorage) GetNameByIndex(namespace string, index int) (string, error) {
+	tasks, err := ts.GetSorted(namespace)
+	if err != nil {
+		return "", err
+	}
+
+	if index > len(tasks) || index < 1 {
+		return "", fmt.Errorf("Wrong task index: %d", index)
+	}
+
+	return tasks[index-1], nil
+}
+
+func (ts *FSTasksStorage) CountLines(namespace string, name string) (int, error) {
+	return countFileLines(ts.taskPath(namespace, name))
+}
+
+// Files have only modification time, it used as creation time too
+func (ts *FSTasksStorage) GetInfo(namespace string, name string) (TaskInfo, error) {
+	name = strings.ReplaceAll(name, "/", PATH_SEPARATOR_REPLACER)
+
+	file, err := os.Open(path.Join(ts.TBaseDir, namespace, name))
+	if err != nil {
+		return TaskInfo{}, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return TaskInfo{}, err
+	}
+
+	head := make([]byte, PREVIEW_LENGTH*4)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return TaskInfo{}, err
+	}
+
+	priority, err := ts.getPriority(namespace, name)
+	if err != nil {
+		return TaskInfo{}, err
+	}
+
+	pinned, err := ts.getPin(namespace, name)
+	if err != nil {
+		return TaskInfo{}, err
+	}
+
+	rank, err := ts.getRank(namespace, name)
+	if err != nil {
+		return TaskInfo{}, err
+	}
+
+	return TaskInfo{
+		Created:  info.ModTime(),
+		Updated:  info.ModTime(),
+		Preview:  preview(head[:n]),
+		Priority: priority,
+		Pinned:   pinned,
+		Rank:     rank,
+	}, nil
+}
+
+// Namespace with tasks and child namespaces is moved by directory renames,
+// destination must not exist or be empty
+func (ts *FSTasksStorage) RenameNamespace(old string, new string) error {
+	found := false
+	for _, root := range ts.namespaceRoots() {
+		found = found || exists(path.Join(root, old))
+	}
+	if !found {
+		return fmt.Errorf("Namespace '%s' not found", old)
+	}
+
+	dstEntries, err := os.ReadDir(path.Join(ts.TBaseDir, new))
+	if err == nil && len(dstEntries) > 0 {
+		return fmt.Errorf("Namespace '%s' already exists", new)
+	}
+
+	// done and time logs left by former namespace with new name are merged,
+	// every root is checked before anything is moved, so rename is not left half done
+	timeRoot := path.Join(ts.TBaseDir, TIME_DIR)
+	for _, root := range ts.namespaceRoots() {
+		err = checkMergeConflict(path.Join(root, old), path.Join(root, new), root == timeRoot)
+		if err != nil {
+			return fmt.Errorf("Namespace '%s' already exists: %s", new, err)
+		}
+	}
+
+	for _, root := range ts.namespaceRoots() {
+		err = mergeDir(path.Join(root, old), path.Join(root, new))
+		if err != nil {
+			return err
+		}
+	}
+
+	return ts.moveActiveTimer(func(namespace string, name string) (string, string, bool) {
+		if namespace != old && !strings.HasPrefix(namespace, old+"/") {
+			return namespace, name, false
+		}
+		return new + strings.TrimPrefix(namespace, old), name, true
+	})
+}
+
+// Moves tasks with metadata, done and time logs to dst, tasks with names existing in dst get suffix,
+// child namespaces stay in place, returns renamed tasks
+func (ts *FSTasksStorage) MergeNamespace(src string, dst string) (map[string]string, error) {
+	tasks, err := os.ReadDir(path.Join(ts.TBaseDir, src))
+	if err != nil {
+		return nil, fmt.Errorf("Namespace '%s' not found", src)
+	}
+
+	err = os.MkdirAll(path.Join(ts.TBaseDir, dst), 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	dstTasks, err := os.ReadDir(path.Join(ts.TBaseDir, dst))
+	if err != nil {
+		return nil, err
+	}
+
+	timeRoot := path.Join(ts.TBaseDir, TIME_DIR)
+	dstTimeLogs, err := fileNames(path.Join(timeRoot, dst))
+	if err != nil {
+		return nil, err
+	}
+
+	// name with time log in dst is taken too, so time of different tasks isn't mixed
+	taken := map[string]bool{}
+	for _, de := range dstTasks {
+		taken[de.Name()] = true
+	}
+	for _, name := range dstTimeLogs {
+		taken[name] = true
+	}
+
+	moved := map[string]bool{}
+	renamed := map[string]string{}
+	for _, de := range tasks {
+		if de.IsDir() {
+			continue
+		}
+
+		name := de.Name()
+		moved[name] = true
+		newName := uniqueName(name, taken)
+		if newName != name {
+			renamed[name] = newName
+		}
+
+		for _, root := range append([]string{ts.TBaseDir}, ts.metadataRoots()...) {
+			err = moveIfExists(path.Join(root, src, name), path.Join(root, dst, newName))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	doneRenamed, err := ts.renameDoneTimeLogs(src, dst, moved, taken)
+	if err != nil {
+		return nil, err
+	}
+
+	doneRoot := path.Join(ts.TBaseDir, DONE_DIR)
+	doneTasks, err := fileNames(path.Join(doneRoot, src))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, doneFile := range doneTasks {
+		newDoneFile := doneFile
+		doneAt, name, found := strings.Cut(doneFile, "_")
+		newName, isRenamed := doneRenamed[name]
+		if found && isRenamed {
+			newDoneFile = doneAt + "_" + newName
+		}
+
+		err = moveIfExists(path.Join(doneRoot, src, doneFile), path.Join(doneRoot, dst, newDoneFile))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	timeLogNames := map[string]string{}
+	for name, newName := range renamed {
+		timeLogNames[name] = newName
+	}
+	for name, newName := range doneRenamed {
+		timeLogNames[name] = newName
+	}
+
+	err = ts.mergeTimeLogs(src, dst, timeLogNames)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ts.moveActiveTimer(func(namespace string, name string) (string, string, bool) {
+		if namespace != src {
+			return namespace, name, false
+		}
+		newName, found := renamed[name]
+		if !found {
+			newName = name
+		}
+		return dst, newName, true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, root := range ts.namespaceRoots() {
+		err = removeIfEmpty(path.Join(root, src))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return renamed, nil
+}
+
+// Time log of done task is found by name of task, so if name is taken in dst
+// done tasks get suffix together with their time log. Returns renamed done tasks
+func (ts *FSTasksStorage) renameDoneTimeLogs(src string, dst string, moved map[string]bool, taken map[string]bool) (map[string]string, error) {
+	dstDoneTasks, err := fileNames(path.Join(ts.TBaseDir, DONE_DIR, dst))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, doneFile := range dstDoneTasks {
+		_, name, found := strings.Cut(doneFile, "_")
+		if found {
+			taken[name] = true
+		}
+	}
+
+	timeLogs, err := fileNames(path.Join(ts.TBaseDir, TIME_DIR, src))
+	if err != nil {
+		return nil, err
+	}
+
+	renamed := map[string]string{}
+	for _, name := range timeLogs {
+		if moved[name] {
+			continue
+		}
+
+		newName := uniqueName(name, taken)
+		if newName != name {
+			renamed[name] = newName
+		}
+	}
+	return renamed, nil
+}
+
+// Removes tasks of namespace with their metadata, child namespaces and done log are kept
+func (ts *FSTasksStorage) DeleteNamespace(namespace string) error {
+	tasks, err := ts.readTasks(namespace)
+	if err != nil {
+		return fmt.Errorf("Namespace '%s' not found", namespace)
+	}
+
+	for _, de := range tasks {
+		err = os.Remove(path.Join(ts.TBaseDir, namespace, de.Name()))
+		if err != nil {
+			return err
+		}
+
+		err = ts.removeMetadata(namespace, de.Name())
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, root := range append([]string{ts.TBaseDir}, ts.metadataRoots()...) {
+		err = removeIfEmpty(path.Join(root, namespace))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Time logs are moved under new names of their tasks, log with the same name in dst is appended
+func (ts *FSTasksStorage) mergeTimeLogs(src string, dst string, renamed map[string]string) error {
+	timeRoot := path.Join(ts.TBaseDir, TIME_DIR)
+	timeLogs, err := os.ReadDir(path.Join(timeRoot, src))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, de := range timeLogs {
+		if de.IsDir() {
+			continue
+		}
+
+		newName, found := renamed[de.Name()]
+		if !found {
+			newName = de.Name()
+		}
+
+		err = appendFile(path.Join(timeRoot, src, de.Name()), path.Join(timeRoot, dst, newName))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ts *FSTasksStorage) metadataRoots() []string {
+	roots := make([]string, 0, len(METADATA_DIRS))
+	for _, metadataDir := range METADATA_DIRS {
+		roots = append(roots, path.Join(ts.TBaseDir, metadataDir))
+	}
+	return roots
+}
+
+// Directories which contain directory per namespace
+func (ts *FSTasksStorage) namespaceRoots() []string {
+	roots := append([]string{ts.TBaseDir}, ts.metadataRoots()...)
+	return append(roots, path.Join(ts.TBaseDir, DONE_DIR), path.Join(ts.TBaseDir, TIME_DIR))
+}
+
+// Names of files in directory, missing directory has no files
+func fileNames(dir string) ([]string, error) {
+	dirEntries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, de := range dirEntries {
+		if !de.IsDir() {
+			names = append(names, de.Name())
+		}
+	}
+	return names, nil
+}
+
+func moveIfExists(src string, dst string) error {
+	if !exists(src) {
+		return nil
+	}
+
+	err := os.MkdirAll(path.Dir(dst), 0755)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(src, dst)
+}
+
+// Returns error if file of src exists in dst, files of appendable logs may exist in both
+func checkMergeConflict(src string, dst string, appendable bool) error {
+	entries, err := os.ReadDir(src)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, de := range entries {
+		dstInfo, err := os.Stat(path.Join(dst, de.Name()))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if de.IsDir() != dstInfo.IsDir() || (!de.IsDir() && !appendable) {
+			return fmt.Errorf("'%s' exists", path.Join(dst, de.Name()))
+		}
+
+		if de.IsDir() {
+			err = checkMergeConflict(path.Join(src, de.Name()), path.Join(dst, de.Name()), appendable)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Moves src to dst, if dst exists its files are kept and files existing in both are appended
+func mergeDir(src string, dst string) error {
+	if !exists(src) {
+		return nil
+	}
+
+	if !exists(dst) {
+		return moveIfExists(src, dst)
+	}
+
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	for _, de := range entries {
+		srcPath, dstPath := path.Join(src, de.Name()), path.Join(dst, de.Name())
+
+		switch {
+		case de.IsDir():
+			err = mergeDir(srcPath, dstPath)
+		case exists(dstPath):
+			err = appendFile(srcPath, dstPath)
+		default:
+			err = os.Rename(srcPath, dstPath)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return os.Remove(src)
+}
+
+// Appends content of src to dst and removes src
+func appendFile(src string, dst string) error {
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(path.Dir(dst), 0755)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(dst, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(content)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(src)
+}
+
+func removeIfEmpty(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(entries) > 0 {
+		return nil
+	}
+	return os.Remove(dir)
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
 }
 
 func countFileLines(filePath string) (int, error) {
@@ -0,0 +1,14 @@
+package storage_test
+
+import (
+	"testing"
+
+	storage "github.com/thek4n/t/internal/storage"
+	"github.com/thek4n/t/internal/storage/storagetest"
+)
+
+func TestFSTasksStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T, sortBy string) storage.TasksStorage {
+		return &storage.FSTasksStorage{TBaseDir: t.TempDir(), Sort: sortBy}
+	})
+}
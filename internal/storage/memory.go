@@ -0,0 +1,591 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Storage of tasks in memory for tests and embedding, not safe for concurrent use
+type MemoryTasksStorage struct {
+	Sort           string
+	Reverse        bool
+	NamespaceSorts map[string]SortOrder
+
+	namespaces map[string]map[string]*memoryTask
+	done       []DoneTask
+	intervals  []Interval
+	timer      *Interval
+}
+
+type memoryTask struct {
+	content    []byte
+	info       TaskInfo
+	recurrence *Recurrence
+}
+
+func (ts *MemoryTasksStorage) tasks(namespace string) map[string]*memoryTask {
+	if ts.namespaces == nil {
+		ts.namespaces = map[string]map[string]*memoryTask{}
+	}
+
+	tasks, found := ts.namespaces[namespace]
+	if !found {
+		tasks = map[string]*memoryTask{}
+		ts.namespaces[namespace] = tasks
+	}
+	return tasks
+}
+
+func (ts *MemoryTasksStorage) task(namespace string, name string) (*memoryTask, error) {
+	task, found := ts.namespaces[namespace][name]
+	if !found {
+		return nil, fmt.Errorf("Task '%s' not found", name)
+	}
+	return task, nil
+}
+
+func (ts *MemoryTasksStorage) touch(task *memoryTask) {
+	task.info.Updated = time.Now()
+}
+
+func (ts *MemoryTasksStorage) GetNamespaces() ([]string, error) {
+	result := []string{}
+	for namespace, tasks := range ts.namespaces {
+		if len(tasks) > 0 {
+			result = append(result, namespace)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+func (ts *MemoryTasksStorage) Count(namespace string) (int, error) {
+	return len(ts.namespaces[namespace]), nil
+}
+
+// Pinned tasks are first, then manually ordered tasks, then not ordered tasks by sort order of namespace
+func (ts *MemoryTasksStorage) GetSorted(namespace string) ([]string, error) {
+	result, err := ts.GetSortedBy(namespace, ts.sortOrder(namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	pins := map[string]time.Time{}
+	ranks := map[string]int{}
+	for name, task := range ts.namespaces[namespace] {
+		if !task.info.Pinned.IsZero() {
+			pins[name] = task.info.Pinned
+		}
+		if task.info.Rank != 0 {
+			ranks[name] = task.info.Rank
+		}
+	}
+	sortPinnedAndRanked(result, pins, ranks)
+
+	return result, nil
+}
+
+// Own order of namespace or default order
+func (ts *MemoryTasksStorage) sortOrder(namespace string) SortOrder {
+	return namespaceOrder(namespace, ts.Sort, ts.Reverse, ts.NamespaceSorts)
+}
+
+// Returns tasks sorted only by order, without pins and manual order
+func (ts *MemoryTasksStorage) GetSortedBy(namespace string, order SortOrder) ([]string, error) {
+	tasks := ts.namespaces[namespace]
+
+	items := make([]sortItem, 0, len(tasks))
+	for name, task := range tasks {
+		items = append(items, sortItem{
+			name:     name,
+			created:  task.info.Created,
+			updated:  task.info.Updated,
+			lines:    bytes.Count(task.content, []byte{'\n'}),
+			priority: task.info.Priority,
+		})
+	}
+
+	sortItems(items, order)
+	return itemNames(items), nil
+}
+
+func (ts *MemoryTasksStorage) GetNameByIndex(namespace string, index int) (string, error) {
+	names, err := ts.getNamesByIndexes(namespace, []int{index})
+	if err != nil {
+		return "", err
+	}
+	return names[0], nil
+}
+
+// Validates all indexes before returning names, so invalid index doesn't cause partial change
+func (ts *MemoryTasksStorage) getNamesByIndexes(namespace string, indexes []int) ([]string, error) {
+	tasks, err := ts.GetSorted(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(indexes))
+	for _, index := range indexes {
+		if index > len(tasks) || index < 1 {
+			return nil, fmt.Errorf("Wrong task index: %d", index)
+		}
+		names = append(names, tasks[index-1])
+	}
+	return names, nil
+}
+
+func (ts *MemoryTasksStorage) GetContentByIndex(namespace string, index int) ([]byte, error) {
+	name, err := ts.GetNameByIndex(namespace, index)
+	if err != nil {
+		return nil, err
+	}
+	return ts.GetContentByName(namespace, name)
+}
+
+func (ts *MemoryTasksStorage) GetContentByName(namespace string, name string) ([]byte, error) {
+	task, err := ts.task(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	task.info.Read = time.Now()
+	return bytes.Clone(task.content), nil
+}
+
+func (ts *MemoryTasksStorage) Add(namespace string, name string) error {
+	tasks := ts.tasks(namespace)
+	if _, found := tasks[name]; found {
+		return fmt.Errorf("Task '%s' already exists", name)
+	}
+
+	task := &memoryTask{content: []byte{}, info: TaskInfo{Created: time.Now()}}
+	ts.touch(task)
+	tasks[name] = task
+	return nil
+}
+
+func (ts *MemoryTasksStorage) WriteByName(namespace string, name string, r io.Reader) error {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	task, found := ts.tasks(namespace)[name]
+	if !found {
+		task = &memoryTask{info: TaskInfo{Created: time.Now()}}
+		ts.namespaces[namespace][name] = task
+	}
+
+	task.content = content
+	ts.touch(task)
+	return nil
+}
+
+func (ts *MemoryTasksStorage) WriteByIndex(namespace string, index int, r io.Reader) error {
+	name, err := ts.GetNameByIndex(namespace, index)
+	if err != nil {
+		return err
+	}
+	return ts.WriteByName(namespace, name, r)
+}
+
+func (ts *MemoryTasksStorage) DeleteByIndexes(namespace string, indexes []int) error {
+	names, err := ts.getNamesByIndexes(namespace, indexes)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		delete(ts.namespaces[namespace], name)
+	}
+	return nil
+}
+
+func (ts *MemoryTasksStorage) CompleteByIndexes(namespace string, indexes []int) error {
+	names, err := ts.getNamesByIndexes(namespace, indexes)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		err = ts.CompleteByName(namespace, name, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ts *MemoryTasksStorage) CompleteByName(namespace string, name string, doneAt time.Time) error {
+	task, err := ts.task(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	delete(ts.namespaces[namespace], name)
+	ts.done = append(ts.done, DoneTask{Namespace: namespace, Name: name, Created: task.info.Created, DoneAt: doneAt})
+
+	if task.recurrence == nil {
+		return nil
+	}
+
+	next, err := nextRecurrence(task.recurrence, time.Now())
+	if err != nil {
+		return err
+	}
+
+	err = ts.WriteByName(namespace, name, bytes.NewReader(next.Template))
+	if err != nil {
+		return err
+	}
+	return ts.SetRecurrence(namespace, name, next)
+}
+
+func (ts *MemoryTasksStorage) GetDone(namespace string, since time.Time) ([]DoneTask, error) {
+	result := []DoneTask{}
+	for _, task := range ts.done {
+		if namespace != "" && task.Namespace != namespace {
+			continue
+		}
+		if task.DoneAt.Before(since) {
+			continue
+		}
+		result = append(result, task)
+	}
+
+	sortDone(result)
+	return result, nil
+}
+
+func (ts *MemoryTasksStorage) CountLines(namespace string, name string) (int, error) {
+	task, err := ts.task(namespace, name)
+	if err != nil {
+		return 0, err
+	}
+	return bytes.Count(task.content, []byte{'\n'}), nil
+}
+
+func (ts *MemoryTasksStorage) GetInfo(namespace string, name string) (TaskInfo, error) {
+	task, err := ts.task(namespace, name)
+	if err != nil {
+		return TaskInfo{}, err
+	}
+
+	info := task.info
+	info.Preview = preview(task.content)
+	return info, nil
+}
+
+// Zero times in info are not changed
+func (ts *MemoryTasksStorage) SetTimes(namespace string, name string, info TaskInfo) error {
+	task, err := ts.task(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	if !info.Created.IsZero() {
+		task.info.Created = info.Created
+	}
+	if !info.Updated.IsZero() {
+		task.info.Updated = info.Updated
+	}
+	if !info.Read.IsZero() {
+		task.info.Read = info.Read
+	}
+	return nil
+}
+
+func (ts *MemoryTasksStorage) SetPriority(namespace string, name string, priority string) error {
+	task, err := ts.task(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	task.info.Priority = priority
+	return nil
+}
+
+// Pinning already pinned task keeps its place among pinned tasks
+func (ts *MemoryTasksStorage) SetPinned(namespace string, name string, pinned bool) error {
+	task, err := ts.task(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	if !pinned {
+		task.info.Pinned = time.Time{}
+	} else if task.info.Pinned.IsZero() {
+		task.info.Pinned = time.Now()
+	}
+	return nil
+}
+
+// Rank 0 removes task from manual order
+func (ts *MemoryTasksStorage) SetRank(namespace string, name string, rank int) error {
+	task, err := ts.task(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	task.info.Rank = rank
+	return nil
+}
+
+func (ts *MemoryTasksStorage) GetRecurrence(namespace string, name string) (*Recurrence, error) {
+	task, err := ts.task(namespace, name)
+	if err != nil || task.recurrence == nil {
+		return nil, nil
+	}
+
+	r := *task.recurrence
+	return &r, nil
+}
+
+func (ts *MemoryTasksStorage) SetRecurrence(namespace string, name string, r *Recurrence) error {
+	task, err := ts.task(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	if r == nil {
+		task.recurrence = nil
+		return nil
+	}
+
+	copied := *r
+	task.recurrence = &copied
+	return nil
+}
+
+// Moves namespace with its child namespaces and done log, destination must have no tasks
+func (ts *MemoryTasksStorage) RenameNamespace(old string, new string) error {
+	found := false
+	for namespace := range ts.namespaces {
+		if isInNamespaceTree(namespace, new) && len(ts.namespaces[namespace]) > 0 {
+			return fmt.Errorf("Namespace '%s' already exists", new)
+		}
+		found = found || (isInNamespaceTree(namespace, old) && len(ts.namespaces[namespace]) > 0)
+	}
+	for _, task := range ts.done {
+		found = found || isInNamespaceTree(task.Namespace, old)
+	}
+
+	if !found {
+		return fmt.Errorf("Namespace '%s' not found", old)
+	}
+
+	moved := map[string]map[string]*memoryTask{}
+	for namespace, tasks := range ts.namespaces {
+		if isInNamespaceTree(namespace, old) {
+			moved[new+strings.TrimPrefix(namespace, old)] = tasks
+			delete(ts.namespaces, namespace)
+		}
+	}
+	for namespace, tasks := range moved {
+		ts.namespaces[namespace] = tasks
+	}
+
+	for i, task := range ts.done {
+		if isInNamespaceTree(task.Namespace, old) {
+			ts.done[i].Namespace = new + strings.TrimPrefix(task.Namespace, old)
+		}
+	}
+
+	for i, interval := range ts.intervals {
+		if isInNamespaceTree(interval.Namespace, old) {
+			ts.intervals[i].Namespace = new + strings.TrimPrefix(interval.Namespace, old)
+		}
+	}
+
+	if ts.timer != nil && isInNamespaceTree(ts.timer.Namespace, old) {
+		ts.timer.Namespace = new + strings.TrimPrefix(ts.timer.Namespace, old)
+	}
+
+	return nil
+}
+
+// Moves tasks with done and time logs to dst, tasks with names existing in dst get suffix,
+// child namespaces stay in place, returns renamed tasks
+func (ts *MemoryTasksStorage) MergeNamespace(src string, dst string) (map[string]string, error) {
+	tasks := ts.namespaces[src]
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("Namespace '%s' not found", src)
+	}
+
+	dstTasks := ts.tasks(dst)
+	taken := map[string]bool{}
+	for name := range dstTasks {
+		taken[name] = true
+	}
+	// name with time log in dst is taken too, so time of different tasks isn't mixed
+	for _, interval := range ts.intervals {
+		if interval.Namespace == dst {
+			taken[interval.Name] = true
+		}
+	}
+
+	names := make([]string, 0, len(tasks))
+	for name := range tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	renamed := map[string]string{}
+	for _, name := range names {
+		newName := uniqueName(name, taken)
+		if newName != name {
+			renamed[name] = newName
+		}
+		dstTasks[newName] = tasks[name]
+	}
+	delete(ts.namespaces, src)
+
+	doneRenamed := ts.renameDoneTimeLogs(src, dst, tasks, taken)
+
+	for i, task := range ts.done {
+		if task.Namespace != src {
+			continue
+		}
+
+		ts.done[i].Namespace = dst
+		newName, found := doneRenamed[task.Name]
+		if found {
+			ts.done[i].Name = newName
+		}
+	}
+
+	for i, interval := range ts.intervals {
+		if interval.Namespace != src {
+			continue
+		}
+
+		ts.intervals[i].Namespace = dst
+		newName, found := renamed[interval.Name]
+		if !found {
+			newName, found = doneRenamed[interval.Name]
+		}
+		if found {
+			ts.intervals[i].Name = newName
+		}
+	}
+
+	if ts.timer != nil && ts.timer.Namespace == src {
+		ts.timer.Namespace = dst
+		newName, found := renamed[ts.timer.Name]
+		if found {
+			ts.timer.Name = newName
+		}
+	}
+
+	return renamed, nil
+}
+
+// Time log of done task is found by name of task, so if name is taken in dst
+// done tasks get suffix together with their time log. Returns renamed done tasks
+func (ts *MemoryTasksStorage) renameDoneTimeLogs(src string, dst string, moved map[string]*memoryTask, taken map[string]bool) map[string]string {
+	for _, task := range ts.done {
+		if task.Namespace == dst {
+			taken[task.Name] = true
+		}
+	}
+
+	logged := map[string]bool{}
+	for _, interval := range ts.intervals {
+		_, isMoved := moved[interval.Name]
+		if interval.Namespace == src && !isMoved {
+			logged[interval.Name] = true
+		}
+	}
+
+	names := make([]string, 0, len(logged))
+	for name := range logged {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	renamed := map[string]string{}
+	for _, name := range names {
+		newName := uniqueName(name, taken)
+		if newName != name {
+			renamed[name] = newName
+		}
+	}
+	return renamed
+}
+
+// Removes tasks of namespace, child namespaces and done log are kept
+func (ts *MemoryTasksStorage) DeleteNamespace(namespace string) error {
+	if len(ts.namespaces[namespace]) == 0 {
+		return fmt.Errorf("Namespace '%s' not found", namespace)
+	}
+
+	delete(ts.namespaces, namespace)
+	return nil
+}
+
+func (ts *MemoryTasksStorage) StartTimer(namespace string, name string, start time.Time) error {
+	_, err := ts.task(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	_, err = ts.StopTimer(start)
+	if err != nil {
+		return err
+	}
+
+	ts.timer = &Interval{Namespace: namespace, Name: name, Start: start}
+	return nil
+}
+
+func (ts *MemoryTasksStorage) StopTimer(end time.Time) (*Interval, error) {
+	if ts.timer == nil {
+		return nil, nil
+	}
+
+	stopped := *ts.timer
+	stopped.End = end
+	ts.intervals = append(ts.intervals, stopped)
+	ts.timer = nil
+	return &stopped, nil
+}
+
+func (ts *MemoryTasksStorage) GetActiveTimer() (*Interval, error) {
+	if ts.timer == nil {
+		return nil, nil
+	}
+
+	active := *ts.timer
+	return &active, nil
+}
+
+func (ts *MemoryTasksStorage) GetIntervals(namespace string, since time.Time) ([]Interval, error) {
+	result := []Interval{}
+	for _, interval := range append(ts.intervals, ts.activeIntervals()...) {
+		if namespace != "" && interval.Namespace != namespace {
+			continue
+		}
+		if !interval.End.IsZero() && interval.End.Before(since) {
+			continue
+		}
+		result = append(result, interval)
+	}
+
+	sortIntervals(result)
+	return result, nil
+}
+
+func (ts *MemoryTasksStorage) activeIntervals() []Interval {
+	if ts.timer == nil {
+		return nil
+	}
+	return []Interval{*ts.timer}
+}
+
+func isInNamespaceTree(namespace string, root string) bool {
+	return namespace == root || strings.HasPrefix(namespace, root+"/")
+}
@@ -0,0 +1,14 @@
+package storage_test
+
+import (
+	"testing"
+
+	storage "github.com/thek4n/t/internal/storage"
+	"github.com/thek4n/t/internal/storage/storagetest"
+)
+
+func TestMemoryTasksStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T, sortBy string) storage.TasksStorage {
+		return &storage.MemoryTasksStorage{Sort: sortBy}
+	})
+}
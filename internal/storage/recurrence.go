@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/thek4n/t/internal/recurrence"
+)
+
+const DATE_FORMAT = "2006-01-02"
+
+func nextRecurrence(r *Recurrence, now time.Time) (*Recurrence, error) {
+	rule, err := recurrence.Parse(r.Rule)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Recurrence{
+		Rule:     r.Rule,
+		Due:      rule.Next(r.Due, now),
+		Template: r.Template,
+	}, nil
+}
+
+func marshalRecurrence(r *Recurrence) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s\n%s\n", r.Rule, r.Due.Format(DATE_FORMAT))
+	buf.Write(r.Template)
+	return buf.Bytes()
+}
+
+func unmarshalRecurrence(content []byte) (*Recurrence, error) {
+	parts := bytes.SplitN(content, []byte{'\n'}, 3)
+	if len(parts) < 3 {
+		return nil, fmt.Errorf("Malformed recurrence")
+	}
+
+	due, err := time.ParseInLocation(DATE_FORMAT, string(parts[1]), time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("Malformed recurrence due date: %s", err)
+	}
+
+	return &Recurrence{
+		Rule:     string(parts[0]),
+		Due:      due,
+		Template: parts[2],
+	}, nil
+}
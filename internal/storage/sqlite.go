@@ -4,13 +4,22 @@ package storage
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	_ "github.com/mattn/go-sqlite3"
 	"io"
+	"strings"
+	"time"
 )
 
+// Format of timestamps stored by DATETIME('now', 'localtime') with appended UTC offset
+const SQL_TIME_FORMAT = "2006-01-02 15:04:05 -0700"
+
 type SqlTasksStorage struct {
-	DbPath string
+	DbPath         string
+	Sort           string
+	Reverse        bool
+	NamespaceSorts map[string]SortOrder
 }
 
 func (ts *SqlTasksStorage) GetNamespaces() ([]string, error) {
@@ -59,14 +68,58 @@ func (ts *SqlTasksStorage) Count(namespace string) (int, error) {
 	return namespacesCount, nil
 }
 
+// Terms of ORDER BY for sort orders, timestamps have second precision, so ties are broken by time of insert
+var SQL_SORT_ORDERS = map[string][][2]string{
+	SORT_UPDATED:  {{"updated_at", "DESC"}, {"rowid", "DESC"}},
+	SORT_CREATED:  {{"created_at", "DESC"}, {"rowid", "DESC"}},
+	SORT_NAME:     {{"name", "ASC"}},
+	SORT_LINES:    {{"LENGTH(content) - LENGTH(REPLACE(content, CHAR(10), ''))", "DESC"}, {"name", "ASC"}},
+	SORT_PRIORITY: {{"priority IS NULL", "ASC"}, {"priority", "ASC"}, {"updated_at", "DESC"}, {"rowid", "DESC"}},
+}
+
+func sqlOrderBy(order SortOrder) string {
+	terms, found := SQL_SORT_ORDERS[order.By]
+	if !found {
+		terms = SQL_SORT_ORDERS[SORT_UPDATED]
+	}
+
+	result := make([]string, 0, len(terms))
+	for _, term := range terms {
+		direction := term[1]
+		if order.Reverse && direction == "ASC" {
+			direction = "DESC"
+		} else if order.Reverse {
+			direction = "ASC"
+		}
+		result = append(result, term[0]+" "+direction)
+	}
+	return strings.Join(result, ", ")
+}
+
+// Pinned tasks are first, then manually ordered tasks, then not ordered tasks by sort order of namespace
 func (ts *SqlTasksStorage) GetSorted(namespace string) ([]string, error) {
+	order := ts.sortOrder(namespace)
+	return ts.querySorted(namespace, "pinned_at IS NULL, pinned_at ASC, rank IS NULL, rank ASC, "+sqlOrderBy(order))
+}
+
+// Own order of namespace or default order
+func (ts *SqlTasksStorage) sortOrder(namespace string) SortOrder {
+	return namespaceOrder(namespace, ts.Sort, ts.Reverse, ts.NamespaceSorts)
+}
+
+// Returns tasks sorted only by order, without pins and manual order
+func (ts *SqlTasksStorage) GetSortedBy(namespace string, order SortOrder) ([]string, error) {
+	return ts.querySorted(namespace, sqlOrderBy(order))
+}
+
+func (ts *SqlTasksStorage) querySorted(namespace string, orderBy string) ([]string, error) {
 	db, err := sql.Open("sqlite3", ts.DbPath)
 	if err != nil {
 		return nil, err
 	}
 	defer db.Close()
 
-	rows, err := db.Query(`SELECT name FROM tasks WHERE namespace = $1 AND deleted = 0 ORDER BY updated_at DESC;`, namespace)
+	rows, err := db.Query(`SELECT name FROM tasks WHERE namespace = $1 AND deleted = 0 ORDER BY `+orderBy+`;`, namespace)
 	if err != nil {
 		return nil, err
 	}
@@ -190,6 +243,132 @@ func (ts *SqlTasksStorage) CountLines(namespace string, name string) (int, error
 	return countRune(string(content), '\n'), nil
 }
 
+func (ts *SqlTasksStorage) GetInfo(namespace string, name string) (TaskInfo, error) {
+	db, err := sql.Open("sqlite3", ts.DbPath)
+	if err != nil {
+		return TaskInfo{}, err
+	}
+	defer db.Close()
+
+	row := db.QueryRow(`
+		SELECT created_at, updated_at, read_at, SUBSTR(content, 1, :length), COALESCE(priority, ''), pinned_at, COALESCE(rank, 0) FROM tasks
+		WHERE namespace = :namespace AND name = :name AND deleted = 0;`,
+		sql.Named("length", PREVIEW_LENGTH*4), sql.Named("namespace", namespace), sql.Named("name", name),
+	)
+
+	var createdAt, updatedAt string
+	var readAt sql.NullString
+	head := []byte{}
+	priority := ""
+	var pinnedAt sql.NullInt64
+	rank := 0
+	err = row.Scan(&createdAt, &updatedAt, &readAt, &head, &priority, &pinnedAt, &rank)
+	if err != nil {
+		return TaskInfo{}, err
+	}
+
+	info, err := parseTaskInfo(createdAt, updatedAt, readAt)
+	info.Preview = preview(head)
+	info.Priority = priority
+	info.Rank = rank
+	if pinnedAt.Valid {
+		info.Pinned = time.Unix(0, pinnedAt.Int64)
+	}
+	return info, err
+}
+
+// Zero times in info are not changed
+func (ts *SqlTasksStorage) SetTimes(namespace string, name string, info TaskInfo) error {
+	db, err := sql.Open("sqlite3", ts.DbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	columns := map[string]time.Time{
+		"created_at": info.Created,
+		"updated_at": info.Updated,
+		"read_at":    info.Read,
+	}
+
+	for column, t := range columns {
+		if t.IsZero() {
+			continue
+		}
+
+		_, err = db.Exec(`UPDATE tasks SET `+column+` = $1 WHERE name = $2 AND namespace = $3 AND deleted = 0;`, t.Local().Format(SQL_TIME_FORMAT), name, namespace)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ts *SqlTasksStorage) SetPriority(namespace string, name string, priority string) error {
+	db, err := sql.Open("sqlite3", ts.DbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`UPDATE tasks SET priority = NULLIF($1, '') WHERE name = $2 AND namespace = $3 AND deleted = 0;`, priority, name, namespace)
+	return err
+}
+
+// Pinning already pinned task keeps its place among pinned tasks
+func (ts *SqlTasksStorage) SetPinned(namespace string, name string, pinned bool) error {
+	db, err := sql.Open("sqlite3", ts.DbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if !pinned {
+		_, err = db.Exec(`UPDATE tasks SET pinned_at = NULL WHERE name = $1 AND namespace = $2 AND deleted = 0;`, name, namespace)
+		return err
+	}
+
+	_, err = db.Exec(`UPDATE tasks SET pinned_at = COALESCE(pinned_at, $1) WHERE name = $2 AND namespace = $3 AND deleted = 0;`, time.Now().UnixNano(), name, namespace)
+	return err
+}
+
+// Rank 0 removes task from manual order
+func (ts *SqlTasksStorage) SetRank(namespace string, name string, rank int) error {
+	db, err := sql.Open("sqlite3", ts.DbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`UPDATE tasks SET rank = NULLIF($1, 0) WHERE name = $2 AND namespace = $3 AND deleted = 0;`, rank, name, namespace)
+	return err
+}
+
+func parseTaskInfo(createdAt string, updatedAt string, readAt sql.NullString) (TaskInfo, error) {
+	var info TaskInfo
+	var err error
+
+	info.Created, err = time.Parse(SQL_TIME_FORMAT, createdAt)
+	if err != nil {
+		return TaskInfo{}, err
+	}
+
+	info.Updated, err = time.Parse(SQL_TIME_FORMAT, updatedAt)
+	if err != nil {
+		return TaskInfo{}, err
+	}
+
+	if readAt.Valid {
+		info.Read, err = time.Parse(SQL_TIME_FORMAT, readAt.String)
+		if err != nil {
+			return TaskInfo{}, err
+		}
+	}
+
+	return info, nil
+}
+
 func (ts *SqlTasksStorage) getContentByName(namespace string, name string) ([]byte, error) {
 	db, err := sql.Open("sqlite3", ts.DbPath)
 	if err != nil {
@@ -229,21 +408,615 @@ func (ts *SqlTasksStorage) DeleteByIndexes(namespace string, indexes []int) erro
 	}
 	defer db.Close()
 
+	names, err := ts.getNamesByIndexes(namespace, indexes)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		_, err = db.Exec(`UPDATE tasks SET deleted = 1, deleted_at = DATETIME('now', 'localtime') || PRINTF(' %+05d', STRFTIME('%H%M', DATE('now')||'T12:00', 'localtime') - STRFTIME('%H%M', DATE('now')||'T12:00')) WHERE name = $1 and namespace = $2 AND deleted = 0`, name, namespace)
+		if err != nil {
+			return err
+		}
+
+		err = ts.SetRecurrence(namespace, name, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ts *SqlTasksStorage) CompleteByIndexes(namespace string, indexes []int) error {
+	names, err := ts.getNamesByIndexes(namespace, indexes)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		err = ts.CompleteByName(namespace, name, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Completed task is soft deleted with done_at timestamp
+func (ts *SqlTasksStorage) CompleteByName(namespace string, name string, doneAt time.Time) error {
+	db, err := sql.Open("sqlite3", ts.DbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	recurrence, err := ts.GetRecurrence(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	result, err := db.Exec(`UPDATE tasks SET deleted = 1, deleted_at = $1, done_at = $1 WHERE name = $2 and namespace = $3 AND deleted = 0`, doneAt.Local().Format(SQL_TIME_FORMAT), name, namespace)
+	if err != nil {
+		return err
+	}
+
+	completed, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if completed == 0 {
+		return fmt.Errorf("Task '%s' not found", name)
+	}
+
+	if recurrence != nil {
+		err = ts.recur(namespace, name, recurrence)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ts *SqlTasksStorage) getNamesByIndexes(namespace string, indexes []int) ([]string, error) {
 	names := []string{}
 	for _, index := range indexes {
 		name, err := ts.GetNameByIndex(namespace, index)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		names = append(names, name)
 	}
+	return names, nil
+}
+
+func (ts *SqlTasksStorage) GetDone(namespace string, since time.Time) ([]DoneTask, error) {
+	db, err := sql.Open("sqlite3", ts.DbPath)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
 
-	for _, name := range names {
-		_, err = db.Exec(`UPDATE tasks SET deleted = 1, deleted_at = DATETIME('now', 'localtime') || PRINTF(' %+05d', STRFTIME('%H%M', DATE('now')||'T12:00', 'localtime') - STRFTIME('%H%M', DATE('now')||'T12:00')) WHERE name = $1 and namespace = $2 AND deleted = 0`, name, namespace)
+	rows, err := db.Query(`
+		SELECT namespace, name, created_at, done_at FROM tasks
+		WHERE done_at IS NOT NULL AND (namespace = :namespace OR :namespace = '');`,
+		sql.Named("namespace", namespace),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []DoneTask{}
+
+	for rows.Next() {
+		var task DoneTask
+		var createdAt, doneAt string
+		err := rows.Scan(&task.Namespace, &task.Name, &createdAt, &doneAt)
+		if err != nil {
+			return nil, err
+		}
+
+		task.Created, err = time.Parse(SQL_TIME_FORMAT, createdAt)
+		if err != nil {
+			return nil, err
+		}
+
+		task.DoneAt, err = time.Parse(SQL_TIME_FORMAT, doneAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if task.DoneAt.Before(since) {
+			continue
+		}
+		result = append(result, task)
+	}
+
+	sortDone(result)
+	return result, nil
+}
+
+// Stops running timer and starts new one in one transaction
+func (ts *SqlTasksStorage) StartTimer(namespace string, name string, start time.Time) error {
+	db, err := sql.Open("sqlite3", ts.DbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var count int
+	err = tx.QueryRow(`SELECT COUNT(1) FROM tasks WHERE name = $1 AND namespace = $2 AND deleted = 0;`, name, namespace).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("Task '%s' not found", name)
+	}
+
+	_, err = tx.Exec(`UPDATE intervals SET ended_at = $1 WHERE ended_at IS NULL;`, start.UnixNano())
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO intervals(name, namespace, started_at) VALUES ($1, $2, $3);`, name, namespace, start.UnixNano())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (ts *SqlTasksStorage) StopTimer(end time.Time) (*Interval, error) {
+	active, err := ts.GetActiveTimer()
+	if err != nil || active == nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite3", ts.DbPath)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`UPDATE intervals SET ended_at = $1 WHERE ended_at IS NULL;`, end.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+
+	active.End = end
+	return active, nil
+}
+
+func (ts *SqlTasksStorage) GetActiveTimer() (*Interval, error) {
+	db, err := sql.Open("sqlite3", ts.DbPath)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var active Interval
+	var startedAt int64
+	err = db.QueryRow(`SELECT namespace, name, started_at FROM intervals WHERE ended_at IS NULL;`).Scan(&active.Namespace, &active.Name, &startedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	active.Start = time.Unix(0, startedAt)
+	return &active, nil
+}
+
+func (ts *SqlTasksStorage) GetIntervals(namespace string, since time.Time) ([]Interval, error) {
+	db, err := sql.Open("sqlite3", ts.DbPath)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// nanoseconds of zero time overflow int64
+	sinceNano := int64(0)
+	if since.After(time.Unix(0, 0)) {
+		sinceNano = since.UnixNano()
+	}
+
+	rows, err := db.Query(`
+		SELECT namespace, name, started_at, ended_at FROM intervals
+		WHERE (namespace = :namespace OR :namespace = '') AND (ended_at IS NULL OR ended_at >= :since);`,
+		sql.Named("namespace", namespace), sql.Named("since", sinceNano),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []Interval{}
+	for rows.Next() {
+		var interval Interval
+		var startedAt int64
+		var endedAt sql.NullInt64
+		err := rows.Scan(&interval.Namespace, &interval.Name, &startedAt, &endedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		interval.Start = time.Unix(0, startedAt)
+		if endedAt.Valid {
+			interval.End = time.Unix(0, endedAt.Int64)
+		}
+		result = append(result, interval)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	sortIntervals(result)
+	return result, nil
+}
+
+// Returns deleted and not completed tasks, recently deleted first
+func (ts *SqlTasksStorage) GetDeleted(namespace string) ([]DeletedTask, error) {
+	db, err := sql.Open("sqlite3", ts.DbPath)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT namespace, name, content, created_at, updated_at, read_at, deleted_at FROM tasks
+		WHERE deleted = 1 AND done_at IS NULL AND namespace = $1
+		ORDER BY deleted_at DESC;`,
+		namespace,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []DeletedTask{}
+
+	for rows.Next() {
+		var task DeletedTask
+		var content []byte
+		var createdAt, updatedAt string
+		var readAt, deletedAt sql.NullString
+		err := rows.Scan(&task.Namespace, &task.Name, &content, &createdAt, &updatedAt, &readAt, &deletedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		task.Info, err = parseTaskInfo(createdAt, updatedAt, readAt)
+		if err != nil {
+			return nil, err
+		}
+		task.Info.Preview = preview(content)
+		task.LinesCount = countRune(string(content), '\n')
+
+		if deletedAt.Valid {
+			task.Deleted, err = parseDeletedAt(deletedAt.String)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		result = append(result, task)
+	}
+
+	return result, nil
+}
+
+// Tasks deleted by previous versions have deleted_at without UTC offset
+func parseDeletedAt(deletedAt string) (time.Time, error) {
+	t, err := time.Parse(SQL_TIME_FORMAT, deletedAt)
+	if err != nil {
+		return time.ParseInLocation("2006-01-02 15:04:05", deletedAt, time.Local)
+	}
+	return t, nil
+}
+
+func (ts *SqlTasksStorage) recur(namespace string, name string, r *Recurrence) error {
+	next, err := nextRecurrence(r, time.Now())
+	if err != nil {
+		return err
+	}
+
+	db, err := sql.Open("sqlite3", ts.DbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`INSERT INTO tasks(name, namespace, content) VALUES($1, $2, $3);`, name, namespace, string(next.Template))
+	if err != nil {
+		return err
+	}
+
+	return ts.SetRecurrence(namespace, name, next)
+}
+
+func (ts *SqlTasksStorage) GetRecurrence(namespace string, name string) (*Recurrence, error) {
+	db, err := sql.Open("sqlite3", ts.DbPath)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	row := db.QueryRow(`
+		SELECT rule, due, template FROM recurrences
+		WHERE namespace = :namespace AND name = :name;`,
+		sql.Named("namespace", namespace), sql.Named("name", name),
+	)
+
+	var rule, due string
+	template := []byte{}
+	err = row.Scan(&rule, &due, &template)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	dueDate, err := time.ParseInLocation(DATE_FORMAT, due, time.Local)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Recurrence{Rule: rule, Due: dueDate, Template: template}, nil
+}
+
+func (ts *SqlTasksStorage) SetRecurrence(namespace string, name string, r *Recurrence) error {
+	db, err := sql.Open("sqlite3", ts.DbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if r == nil {
+		_, err = db.Exec(`DELETE FROM recurrences WHERE namespace = $1 AND name = $2;`, namespace, name)
+		return err
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO recurrences(name, namespace, rule, due, template) VALUES($1, $2, $3, $4, $5)
+		ON CONFLICT(name, namespace) DO UPDATE SET rule = excluded.rule, due = excluded.due, template = excluded.template;`,
+		name, namespace, r.Rule, r.Due.Format(DATE_FORMAT), string(r.Template),
+	)
+	return err
+}
+
+// Condition matching namespace given by named parameter 'namespace' and its child namespaces
+const SQL_NAMESPACE_TREE = `(namespace = :namespace OR SUBSTR(namespace, 1, LENGTH(:namespace) + 1) = :namespace || '/')`
+
+// Moves tasks of namespace and its child namespaces with done and time logs in one transaction,
+// destination must have no tasks
+func (ts *SqlTasksStorage) RenameNamespace(old string, new string) error {
+	db, err := sql.Open("sqlite3", ts.DbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var oldCount, newCount int
+	err = tx.QueryRow(`SELECT COUNT(1) FROM tasks WHERE `+SQL_NAMESPACE_TREE+`;`, sql.Named("namespace", old)).Scan(&oldCount)
+	if err != nil {
+		return err
+	}
+	if oldCount == 0 {
+		return fmt.Errorf("Namespace '%s' not found", old)
+	}
+
+	err = tx.QueryRow(`SELECT COUNT(1) FROM tasks WHERE `+SQL_NAMESPACE_TREE+` AND deleted = 0;`, sql.Named("namespace", new)).Scan(&newCount)
+	if err != nil {
+		return err
+	}
+	if newCount > 0 {
+		return fmt.Errorf("Namespace '%s' already exists", new)
+	}
+
+	for _, table := range []string{"tasks", "recurrences", "intervals"} {
+		_, err = tx.Exec(
+			`UPDATE `+table+` SET namespace = :new || SUBSTR(namespace, LENGTH(:namespace) + 1) WHERE `+SQL_NAMESPACE_TREE+`;`,
+			sql.Named("new", new), sql.Named("namespace", old),
+		)
 		if err != nil {
 			return err
 		}
 	}
 
+	return tx.Commit()
+}
+
+// Moves tasks with done and time logs to dst in one transaction, tasks with names existing in dst get suffix,
+// returns renamed tasks
+func (ts *SqlTasksStorage) MergeNamespace(src string, dst string) (map[string]string, error) {
+	db, err := sql.Open("sqlite3", ts.DbPath)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	tasks, err := queryNames(tx, src)
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("Namespace '%s' not found", src)
+	}
+
+	dstTasks, err := queryNames(tx, dst)
+	if err != nil {
+		return nil, err
+	}
+
+	dstTimeLogs, err := queryStrings(tx, `SELECT DISTINCT name FROM intervals WHERE namespace = $1;`, dst)
+	if err != nil {
+		return nil, err
+	}
+
+	// name with time log in dst is taken too, so time of different tasks isn't mixed
+	taken := map[string]bool{}
+	for _, name := range append(dstTasks, dstTimeLogs...) {
+		taken[name] = true
+	}
+
+	renamed := map[string]string{}
+	for _, name := range tasks {
+		newName := uniqueName(name, taken)
+		if newName != name {
+			renamed[name] = newName
+		}
+
+		_, err = tx.Exec(`UPDATE tasks SET namespace = $1, name = $2 WHERE namespace = $3 AND name = $4 AND deleted = 0;`, dst, newName, src, name)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec(`UPDATE recurrences SET namespace = $1, name = $2 WHERE namespace = $3 AND name = $4;`, dst, newName, src, name)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec(`UPDATE intervals SET namespace = $1, name = $2 WHERE namespace = $3 AND name = $4;`, dst, newName, src, name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = renameDoneTimeLogs(tx, src, dst, taken)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`UPDATE tasks SET namespace = $1 WHERE namespace = $2 AND deleted = 1;`, dst, src)
+	if err != nil {
+		return nil, err
+	}
+
+	// time logs of done tasks
+	_, err = tx.Exec(`UPDATE intervals SET namespace = $1 WHERE namespace = $2;`, dst, src)
+	if err != nil {
+		return nil, err
+	}
+
+	return renamed, tx.Commit()
+}
+
+// Time log of done task is found by name of task, so if name is taken in dst
+// done tasks get suffix together with their time log. Pending tasks of src must be moved already
+func renameDoneTimeLogs(tx *sql.Tx, src string, dst string, taken map[string]bool) error {
+	dstDone, err := queryStrings(tx, `SELECT DISTINCT name FROM tasks WHERE namespace = $1 AND deleted = 1;`, dst)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range dstDone {
+		taken[name] = true
+	}
+
+	timeLogs, err := queryStrings(tx, `SELECT DISTINCT name FROM intervals WHERE namespace = $1 ORDER BY name;`, src)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range timeLogs {
+		newName := uniqueName(name, taken)
+		if newName == name {
+			continue
+		}
+
+		_, err = tx.Exec(`UPDATE tasks SET name = $1 WHERE namespace = $2 AND name = $3 AND deleted = 1;`, newName, src, name)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`UPDATE intervals SET name = $1 WHERE namespace = $2 AND name = $3;`, newName, src, name)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// Soft deletes tasks of namespace in one transaction
+func (ts *SqlTasksStorage) DeleteNamespace(namespace string) error {
+	db, err := sql.Open("sqlite3", ts.DbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE tasks SET deleted = 1, deleted_at = $1 WHERE namespace = $2 AND deleted = 0;`, time.Now().Format(SQL_TIME_FORMAT), namespace)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return fmt.Errorf("Namespace '%s' not found", namespace)
+	}
+
+	_, err = tx.Exec(`DELETE FROM recurrences WHERE namespace = $1;`, namespace)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func queryNames(tx *sql.Tx, namespace string) ([]string, error) {
+	return queryStrings(tx, `SELECT name FROM tasks WHERE namespace = $1 AND deleted = 0;`, namespace)
+}
+
+func queryStrings(tx *sql.Tx, query string, args ...any) ([]string, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		name := ""
+		err := rows.Scan(&name)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
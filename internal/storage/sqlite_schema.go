@@ -0,0 +1,166 @@
+//go:build tsqlite
+
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+const SQLITE_DB_FILE = "t.sqlite3"
+
+// Opens database in file dbPath, creates schema and migrates databases of previous versions
+func OpenSqlite(dbPath string, sortBy string) (*SqlTasksStorage, error) {
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	err = migrateUniqueConstraint(db)
+	if err != nil {
+		return nil, fmt.Errorf("Error migrating database: %s", err)
+	}
+
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS
+	tasks(
+		name VARCHAR(150) NOT NULL,
+		namespace VARCHAR(30) NOT NULL,
+		content TEXT NOT NULL,
+		created_at TEXT DEFAULT (DATETIME('now', 'localtime') || PRINTF(' %+05d', STRFTIME('%H%M', DATE('now')||'T12:00', 'localtime') - STRFTIME('%H%M', DATE('now')||'T12:00'))) NOT NULL,
+		updated_at TEXT DEFAULT (DATETIME('now', 'localtime') || PRINTF(' %+05d', STRFTIME('%H%M', DATE('now')||'T12:00', 'localtime') - STRFTIME('%H%M', DATE('now')||'T12:00'))) NOT NULL,
+		read_at TEXT NULL,
+		deleted_at TEXT NULL,
+		deleted INTEGER DEFAULT 0 CHECK(deleted IN (0, 1)));
+
+	CREATE UNIQUE INDEX IF NOT EXISTS tasks_name_namespace ON tasks(name, namespace) WHERE deleted = 0;
+
+	CREATE TABLE IF NOT EXISTS
+	recurrences(
+		name VARCHAR(150) NOT NULL,
+		namespace VARCHAR(30) NOT NULL,
+		rule TEXT NOT NULL,
+		due TEXT NOT NULL,
+		template TEXT NOT NULL,
+		UNIQUE (name, namespace));
+	`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = addColumnIfNotExists(db, "tasks", "done_at", "TEXT NULL")
+	if err != nil {
+		return nil, fmt.Errorf("Error migrating database: %s", err)
+	}
+
+	err = addColumnIfNotExists(db, "tasks", "priority", "TEXT NULL")
+	if err != nil {
+		return nil, fmt.Errorf("Error migrating database: %s", err)
+	}
+
+	// unix nanoseconds, so pinned tasks keep exact order of pinning
+	err = addColumnIfNotExists(db, "tasks", "pinned_at", "INTEGER NULL")
+	if err != nil {
+		return nil, fmt.Errorf("Error migrating database: %s", err)
+	}
+
+	err = addColumnIfNotExists(db, "tasks", "rank", "INTEGER NULL")
+	if err != nil {
+		return nil, fmt.Errorf("Error migrating database: %s", err)
+	}
+
+	// indexes of sort orders
+	_, err = db.Exec(`
+	CREATE INDEX IF NOT EXISTS tasks_namespace_name ON tasks(namespace, name) WHERE deleted = 0;
+	CREATE INDEX IF NOT EXISTS tasks_namespace_updated ON tasks(namespace, updated_at) WHERE deleted = 0;
+	CREATE INDEX IF NOT EXISTS tasks_namespace_created ON tasks(namespace, created_at) WHERE deleted = 0;
+	CREATE INDEX IF NOT EXISTS tasks_namespace_priority ON tasks(namespace, priority IS NULL, priority, updated_at) WHERE deleted = 0;
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	// unix nanoseconds, interval without end is running timer and only one can run
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS
+	intervals(
+		name VARCHAR(150) NOT NULL,
+		namespace VARCHAR(30) NOT NULL,
+		started_at INTEGER NOT NULL,
+		ended_at INTEGER NULL);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS intervals_active ON intervals(ended_at IS NULL) WHERE ended_at IS NULL;
+	CREATE INDEX IF NOT EXISTS intervals_namespace_ended ON intervals(namespace, ended_at);
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SqlTasksStorage{DbPath: dbPath, Sort: sortBy}, nil
+}
+
+func addColumnIfNotExists(db *sql.DB, table string, column string, definition string) error {
+	row := db.QueryRow(`SELECT COUNT(1) FROM pragma_table_info($1) WHERE name = $2;`, table, column)
+
+	columnsCount := 0
+	err := row.Scan(&columnsCount)
+	if err != nil {
+		return err
+	}
+
+	if columnsCount > 0 {
+		return nil
+	}
+
+	_, err = db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s;`, table, column, definition))
+	return err
+}
+
+// Databases created before recurrences have UNIQUE (name, namespace) on all rows,
+// so soft deleted task blocks creating task with same name
+func migrateUniqueConstraint(db *sql.DB) error {
+	row := db.QueryRow(`SELECT COUNT(1) FROM sqlite_master WHERE type = 'index' AND name = 'sqlite_autoindex_tasks_1';`)
+
+	oldConstraintsCount := 0
+	err := row.Scan(&oldConstraintsCount)
+	if err != nil {
+		return err
+	}
+
+	if oldConstraintsCount == 0 {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+	ALTER TABLE tasks RENAME TO tasks_old;
+
+	CREATE TABLE
+	tasks(
+		name VARCHAR(150) NOT NULL,
+		namespace VARCHAR(30) NOT NULL,
+		content TEXT NOT NULL,
+		created_at TEXT DEFAULT (DATETIME('now', 'localtime') || PRINTF(' %+05d', STRFTIME('%H%M', DATE('now')||'T12:00', 'localtime') - STRFTIME('%H%M', DATE('now')||'T12:00'))) NOT NULL,
+		updated_at TEXT DEFAULT (DATETIME('now', 'localtime') || PRINTF(' %+05d', STRFTIME('%H%M', DATE('now')||'T12:00', 'localtime') - STRFTIME('%H%M', DATE('now')||'T12:00'))) NOT NULL,
+		read_at TEXT NULL,
+		deleted_at TEXT NULL,
+		deleted INTEGER DEFAULT 0 CHECK(deleted IN (0, 1)));
+
+	INSERT INTO tasks(name, namespace, content, created_at, updated_at, read_at, deleted_at, deleted)
+	SELECT name, namespace, content, created_at, updated_at, read_at, deleted_at, deleted FROM tasks_old;
+
+	DROP TABLE tasks_old;
+	`)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
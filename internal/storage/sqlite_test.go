@@ -0,0 +1,36 @@
+//go:build tsqlite
+
+package storage_test
+
+import (
+	"path"
+	"testing"
+
+	storage "github.com/thek4n/t/internal/storage"
+	"github.com/thek4n/t/internal/storage/storagetest"
+)
+
+func TestSqlTasksStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T, sortBy string) storage.TasksStorage {
+		s, err := storage.OpenSqlite(path.Join(t.TempDir(), storage.SQLITE_DB_FILE), sortBy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	})
+}
+
+func TestEncryptedSqlTasksStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T, sortBy string) storage.TasksStorage {
+		s, err := storage.OpenSqlite(path.Join(t.TempDir(), storage.SQLITE_DB_FILE), sortBy)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		encrypted, err := storage.NewEncryptedStorage(s, testKey, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return encrypted
+	})
+}
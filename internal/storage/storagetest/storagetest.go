@@ -0,0 +1,787 @@
+// Package storagetest is conformance suite which every implementation of storage.TasksStorage must pass
+package storagetest
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	storage "github.com/thek4n/t/internal/storage"
+)
+
+const NS = "def"
+
+// Returns new empty storage sorting tasks by sortBy
+type Factory func(t *testing.T, sortBy string) storage.TasksStorage
+
+func Run(t *testing.T, newStorage Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, newStorage Factory)
+	}{
+		{"Add", testAdd},
+		{"AddExisting", testAddExisting},
+		{"OrderUpdated", testOrderUpdated},
+		{"OrderName", testOrderName},
+		{"SortOrders", testSortOrders},
+		{"IndexBounds", testIndexBounds},
+		{"NameEncoding", testNameEncoding},
+		{"ChildNamespaces", testChildNamespaces},
+		{"Delete", testDelete},
+		{"Complete", testComplete},
+		{"CountLines", testCountLines},
+		{"Metadata", testMetadata},
+		{"Pin", testPin},
+		{"Rank", testRank},
+		{"Timer", testTimer},
+		{"TimerNamespaces", testTimerNamespaces},
+		{"RenameNamespace", testRenameNamespace},
+		{"RenameNamespaceToDoneLog", testRenameNamespaceToDoneLog},
+		{"MergeNamespace", testMergeNamespace},
+		{"MergeNamespaceTimeLogs", testMergeNamespaceTimeLogs},
+		{"DeleteNamespace", testDeleteNamespace},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStorage)
+		})
+	}
+}
+
+func testAdd(t *testing.T, newStorage Factory) {
+	s := newStorage(t, storage.SORT_UPDATED)
+	add(t, s, NS, "buy bread")
+
+	expectNames(t, s, NS, "buy bread")
+	expectCount(t, s, NS, 1)
+	expectContent(t, s, NS, "buy bread", "")
+
+	namespaces, err := s.GetNamespaces()
+	must(t, err)
+	if !contains(namespaces, NS) {
+		t.Errorf("GetNamespaces() = %v, expected to contain %s", namespaces, NS)
+	}
+}
+
+func testAddExisting(t *testing.T, newStorage Factory) {
+	s := newStorage(t, storage.SORT_UPDATED)
+	add(t, s, NS, "task")
+	write(t, s, NS, "task", "content\n")
+
+	err := s.Add(NS, "task")
+	if err == nil {
+		t.Errorf("Add() of existing task expected to fail")
+	}
+
+	expectCount(t, s, NS, 1)
+	expectContent(t, s, NS, "task", "content\n")
+}
+
+func testOrderUpdated(t *testing.T, newStorage Factory) {
+	s := newStorage(t, storage.SORT_UPDATED)
+	now := time.Now()
+
+	for i, name := range []string{"a", "b", "c"} {
+		add(t, s, NS, name)
+		must(t, s.SetTimes(NS, name, storage.TaskInfo{Updated: now.Add(-time.Duration(3-i) * time.Hour)}))
+	}
+	expectNames(t, s, NS, "c", "b", "a")
+
+	write(t, s, NS, "a", "updated\n")
+	expectNames(t, s, NS, "a", "c", "b")
+}
+
+func testOrderName(t *testing.T, newStorage Factory) {
+	s := newStorage(t, storage.SORT_NAME)
+
+	for _, name := range []string{"b", "c", "a"} {
+		add(t, s, NS, name)
+	}
+	expectNames(t, s, NS, "a", "b", "c")
+}
+
+func testSortOrders(t *testing.T, newStorage Factory) {
+	s := newStorage(t, storage.SORT_UPDATED)
+	now := time.Now()
+
+	tasks := []struct {
+		name     string
+		content  string
+		priority string
+		age      time.Duration
+	}{
+		{"b", "1\n2\n3\n", "", 3 * time.Hour},
+		{"a", "1\n", "B", 1 * time.Hour},
+		{"d", "", "A", 2 * time.Hour},
+		{"c", "1\n2\n", "B", 4 * time.Hour},
+	}
+
+	for _, task := range tasks {
+		add(t, s, NS, task.name)
+		write(t, s, NS, task.name, task.content)
+		must(t, s.SetPriority(NS, task.name, task.priority))
+		must(t, s.SetTimes(NS, task.name, storage.TaskInfo{Created: now.Add(-task.age), Updated: now.Add(-task.age)}))
+	}
+
+	cases := []struct {
+		order    string
+		expected []string
+	}{
+		{"updated", []string{"a", "d", "b", "c"}},
+		{"updated reverse", []string{"c", "b", "d", "a"}},
+		{"created", []string{"a", "d", "b", "c"}},
+		{"name", []string{"a", "b", "c", "d"}},
+		{"name reverse", []string{"d", "c", "b", "a"}},
+		{"lines", []string{"b", "c", "a", "d"}},
+		{"priority", []string{"d", "a", "c", "b"}},
+		{"priority reverse", []string{"b", "c", "a", "d"}},
+	}
+
+	for _, tt := range cases {
+		order, err := storage.ParseSortOrder(tt.order)
+		must(t, err)
+
+		names, err := s.GetSortedBy(NS, order)
+		must(t, err)
+		if !reflect.DeepEqual(names, tt.expected) {
+			t.Errorf("GetSortedBy(%s) = %q, expected %q", tt.order, names, tt.expected)
+		}
+	}
+
+	must(t, s.SetPinned(NS, "c", true))
+	names, err := s.GetSortedBy(NS, storage.SortOrder{By: storage.SORT_NAME})
+	must(t, err)
+	if !reflect.DeepEqual(names, []string{"a", "b", "c", "d"}) {
+		t.Errorf("GetSortedBy(name) with pinned task = %q, expected order without pins", names)
+	}
+}
+
+func testIndexBounds(t *testing.T, newStorage Factory) {
+	s := newStorage(t, storage.SORT_NAME)
+	add(t, s, NS, "a")
+	add(t, s, NS, "b")
+
+	name, err := s.GetNameByIndex(NS, 2)
+	must(t, err)
+	if name != "b" {
+		t.Errorf("GetNameByIndex(2) = %q, expected %q", name, "b")
+	}
+
+	for _, index := range []int{-1, 0, 3} {
+		_, err = s.GetNameByIndex(NS, index)
+		expectError(t, err, "GetNameByIndex(%d)", index)
+
+		_, err = s.GetContentByIndex(NS, index)
+		expectError(t, err, "GetContentByIndex(%d)", index)
+
+		err = s.WriteByIndex(NS, index, strings.NewReader("x"))
+		expectError(t, err, "WriteByIndex(%d)", index)
+	}
+
+	err = s.DeleteByIndexes(NS, []int{1, 3})
+	expectError(t, err, "DeleteByIndexes([1 3])")
+
+	err = s.CompleteByIndexes(NS, []int{2, 0})
+	expectError(t, err, "CompleteByIndexes([2 0])")
+
+	expectNames(t, s, NS, "a", "b")
+}
+
+func testNameEncoding(t *testing.T, newStorage Factory) {
+	s := newStorage(t, storage.SORT_NAME)
+	add(t, s, NS, "read a/b testing")
+
+	names, err := s.GetSorted(NS)
+	must(t, err)
+	if len(names) != 1 {
+		t.Fatalf("GetSorted() = %q, expected one task", names)
+	}
+	stored := names[0]
+
+	write(t, s, NS, "read a/b testing", "one\ntwo\n")
+	expectCount(t, s, NS, 1)
+	expectContent(t, s, NS, stored, "one\ntwo\n")
+	expectContent(t, s, NS, "read a/b testing", "one\ntwo\n")
+
+	lines, err := s.CountLines(NS, "read a/b testing")
+	must(t, err)
+	if lines != 2 {
+		t.Errorf("CountLines() = %d, expected 2", lines)
+	}
+
+	info, err := s.GetInfo(NS, stored)
+	must(t, err)
+	if info.Preview != "one" {
+		t.Errorf("GetInfo().Preview = %q, expected %q", info.Preview, "one")
+	}
+}
+
+func testChildNamespaces(t *testing.T, newStorage Factory) {
+	s := newStorage(t, storage.SORT_NAME)
+	add(t, s, "work", "plan")
+	add(t, s, "work/backend", "api")
+
+	expectNames(t, s, "work", "plan")
+	expectCount(t, s, "work", 1)
+	expectNames(t, s, "work/backend", "api")
+
+	namespaces, err := s.GetNamespaces()
+	must(t, err)
+	if !contains(namespaces, "work") || !contains(namespaces, "work/backend") {
+		t.Errorf("GetNamespaces() = %v, expected to contain work and work/backend", namespaces)
+	}
+}
+
+func testDelete(t *testing.T, newStorage Factory) {
+	s := newStorage(t, storage.SORT_NAME)
+	for _, name := range []string{"a", "b", "c"} {
+		add(t, s, NS, name)
+	}
+	must(t, s.SetRecurrence(NS, "a", &storage.Recurrence{Rule: "daily", Due: today(), Template: []byte{}}))
+
+	must(t, s.DeleteByIndexes(NS, []int{1, 3}))
+	expectNames(t, s, NS, "b")
+
+	done, err := s.GetDone(NS, time.Time{})
+	must(t, err)
+	if len(done) != 0 {
+		t.Errorf("GetDone() after delete = %v, expected empty", done)
+	}
+
+	add(t, s, NS, "a")
+	expectContent(t, s, NS, "a", "")
+
+	r, err := s.GetRecurrence(NS, "a")
+	must(t, err)
+	if r != nil {
+		t.Errorf("GetRecurrence() of task added after delete = %+v, expected nil", r)
+	}
+}
+
+func testComplete(t *testing.T, newStorage Factory) {
+	s := newStorage(t, storage.SORT_NAME)
+	add(t, s, NS, "a")
+	add(t, s, NS, "b")
+	add(t, s, "other", "c")
+
+	created := time.Now().Add(-72 * time.Hour).Truncate(time.Second)
+	must(t, s.SetTimes("other", "c", storage.TaskInfo{Created: created}))
+
+	must(t, s.CompleteByIndexes(NS, []int{2}))
+	expectNames(t, s, NS, "a")
+
+	doneAt := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	must(t, s.CompleteByName("other", "c", doneAt))
+	expectNames(t, s, "other")
+
+	err := s.CompleteByName(NS, "missing", time.Now())
+	expectError(t, err, "CompleteByName() of missing task")
+
+	done, err := s.GetDone(NS, time.Time{})
+	must(t, err)
+	if len(done) != 1 || done[0].Name != "b" || done[0].Namespace != NS {
+		t.Errorf("GetDone(%s) = %+v, expected task b", NS, done)
+	}
+
+	done, err = s.GetDone("", time.Time{})
+	must(t, err)
+	if len(done) != 2 || done[1].Name != "c" || !done[1].DoneAt.Equal(doneAt) {
+		t.Errorf("GetDone() = %+v, expected b and c done at %s", done, doneAt)
+	}
+	if len(done) == 2 && !done[1].Created.Equal(created) {
+		t.Errorf("GetDone() = %+v, expected c created at %s", done, created)
+	}
+
+	done, err = s.GetDone("", time.Now().Add(-time.Hour))
+	must(t, err)
+	if len(done) != 1 {
+		t.Errorf("GetDone() since hour ago = %+v, expected only b", done)
+	}
+}
+
+func testCountLines(t *testing.T, newStorage Factory) {
+	s := newStorage(t, storage.SORT_NAME)
+
+	cases := map[string]int{
+		"":              0,
+		"one":           0,
+		"one\n":         1,
+		"one\ntwo\n":    2,
+		"one\ntwo":      1,
+		"\n\n\n":        3,
+		"юникод\nтекст": 1,
+	}
+
+	i := 0
+	for content, expected := range cases {
+		name := string(rune('a' + i))
+		i++
+
+		add(t, s, NS, name)
+		write(t, s, NS, name, content)
+
+		lines, err := s.CountLines(NS, name)
+		must(t, err)
+		if lines != expected {
+			t.Errorf("CountLines() of %q = %d, expected %d", content, lines, expected)
+		}
+	}
+}
+
+func testMetadata(t *testing.T, newStorage Factory) {
+	s := newStorage(t, storage.SORT_NAME)
+	add(t, s, NS, "task")
+
+	must(t, s.SetPriority(NS, "task", "A"))
+	expectPriority(t, s, NS, "task", "A")
+	must(t, s.SetPriority(NS, "task", ""))
+	expectPriority(t, s, NS, "task", "")
+
+	r := &storage.Recurrence{Rule: "daily", Due: today(), Template: []byte("template\n")}
+	must(t, s.SetRecurrence(NS, "task", r))
+
+	got, err := s.GetRecurrence(NS, "task")
+	must(t, err)
+	if got == nil || got.Rule != r.Rule || !got.Due.Equal(r.Due) || !bytes.Equal(got.Template, r.Template) {
+		t.Errorf("GetRecurrence() = %+v, expected %+v", got, r)
+	}
+
+	must(t, s.CompleteByName(NS, "task", time.Now()))
+	expectContent(t, s, NS, "task", "template\n")
+
+	got, err = s.GetRecurrence(NS, "task")
+	must(t, err)
+	if got == nil || !got.Due.After(r.Due) {
+		t.Errorf("GetRecurrence() after complete = %+v, expected next occurrence", got)
+	}
+
+	must(t, s.SetRecurrence(NS, "task", nil))
+	got, err = s.GetRecurrence(NS, "task")
+	must(t, err)
+	if got != nil {
+		t.Errorf("GetRecurrence() after removal = %+v, expected nil", got)
+	}
+}
+
+func testPin(t *testing.T, newStorage Factory) {
+	s := newStorage(t, storage.SORT_UPDATED)
+	now := time.Now()
+
+	for i, name := range []string{"a", "b", "c"} {
+		add(t, s, NS, name)
+		must(t, s.SetTimes(NS, name, storage.TaskInfo{Updated: now.Add(-time.Duration(3-i) * time.Hour)}))
+	}
+
+	before, err := s.GetInfo(NS, "a")
+	must(t, err)
+
+	must(t, s.SetPinned(NS, "a", true))
+	must(t, s.SetPinned(NS, "b", true))
+	must(t, s.SetPinned(NS, "a", true))
+	expectNames(t, s, NS, "a", "b", "c")
+
+	after, err := s.GetInfo(NS, "a")
+	must(t, err)
+	if after.Pinned.IsZero() || !after.Updated.Equal(before.Updated) {
+		t.Errorf("GetInfo() after pin = %+v, expected pinned with updated time %s", after, before.Updated)
+	}
+
+	write(t, s, NS, "b", "edited\n")
+	write(t, s, NS, "c", "edited\n")
+	expectNames(t, s, NS, "a", "b", "c")
+
+	must(t, s.SetPinned(NS, "a", false))
+	expectNames(t, s, NS, "b", "c", "a")
+
+	must(t, s.DeleteByIndexes(NS, []int{1}))
+	add(t, s, NS, "b")
+	info, err := s.GetInfo(NS, "b")
+	must(t, err)
+	if !info.Pinned.IsZero() {
+		t.Errorf("GetInfo() of task added after delete of pinned = %+v, expected not pinned", info)
+	}
+}
+
+func testRank(t *testing.T, newStorage Factory) {
+	s := newStorage(t, storage.SORT_NAME)
+	for _, name := range []string{"a", "b", "c", "d"} {
+		add(t, s, NS, name)
+	}
+
+	must(t, s.SetRank(NS, "c", 1))
+	must(t, s.SetRank(NS, "a", 2))
+	expectNames(t, s, NS, "c", "a", "b", "d")
+
+	write(t, s, NS, "a", "edited\n")
+	expectNames(t, s, NS, "c", "a", "b", "d")
+
+	add(t, s, NS, "0 new")
+	expectNames(t, s, NS, "c", "a", "0 new", "b", "d")
+
+	info, err := s.GetInfo(NS, "a")
+	must(t, err)
+	if info.Rank != 2 {
+		t.Errorf("GetInfo().Rank = %d, expected 2", info.Rank)
+	}
+
+	must(t, s.SetPinned(NS, "a", true))
+	expectNames(t, s, NS, "a", "c", "0 new", "b", "d")
+
+	must(t, s.SetRank(NS, "c", 0))
+	expectNames(t, s, NS, "a", "0 new", "b", "c", "d")
+}
+
+func testTimer(t *testing.T, newStorage Factory) {
+	s := newStorage(t, storage.SORT_NAME)
+	add(t, s, NS, "a")
+	add(t, s, NS, "b")
+	add(t, s, "other", "c")
+	start := time.Now().Add(-3 * time.Hour)
+
+	expectActiveTimer(t, s, nil)
+	stopped, err := s.StopTimer(start)
+	must(t, err)
+	if stopped != nil {
+		t.Errorf("StopTimer() without running timer = %+v, expected nil", stopped)
+	}
+
+	expectError(t, s.StartTimer(NS, "missing", start), "StartTimer() of missing task")
+
+	must(t, s.StartTimer(NS, "a", start))
+	expectActiveTimer(t, s, &storage.Interval{Namespace: NS, Name: "a", Start: start})
+
+	must(t, s.StartTimer(NS, "b", start.Add(time.Hour)))
+	expectActiveTimer(t, s, &storage.Interval{Namespace: NS, Name: "b", Start: start.Add(time.Hour)})
+
+	stopped, err = s.StopTimer(start.Add(90 * time.Minute))
+	must(t, err)
+	expected := storage.Interval{Namespace: NS, Name: "b", Start: start.Add(time.Hour), End: start.Add(90 * time.Minute)}
+	if stopped == nil || !equalIntervals([]storage.Interval{*stopped}, []storage.Interval{expected}) {
+		t.Errorf("StopTimer() = %+v, expected %+v", stopped, expected)
+	}
+	expectActiveTimer(t, s, nil)
+
+	must(t, s.StartTimer("other", "c", start.Add(2*time.Hour)))
+
+	expectIntervals(t, s, NS, time.Time{},
+		storage.Interval{Namespace: NS, Name: "a", Start: start, End: start.Add(time.Hour)},
+		expected,
+	)
+	expectIntervals(t, s, "", start.Add(80*time.Minute),
+		expected,
+		storage.Interval{Namespace: "other", Name: "c", Start: start.Add(2 * time.Hour)},
+	)
+
+	must(t, s.CompleteByName(NS, "a", time.Now()))
+	expectIntervals(t, s, NS, time.Time{},
+		storage.Interval{Namespace: NS, Name: "a", Start: start, End: start.Add(time.Hour)},
+		expected,
+	)
+}
+
+func testTimerNamespaces(t *testing.T, newStorage Factory) {
+	s := newStorage(t, storage.SORT_NAME)
+	add(t, s, "src", "a")
+	add(t, s, "src", "b")
+	add(t, s, "dst", "b")
+	start := time.Now().Add(-time.Hour)
+
+	must(t, s.StartTimer("src", "b", start))
+	must(t, s.StartTimer("src", "a", start.Add(time.Minute)))
+
+	must(t, s.RenameNamespace("src", "source"))
+	expectActiveTimer(t, s, &storage.Interval{Namespace: "source", Name: "a", Start: start.Add(time.Minute)})
+	must(t, s.StartTimer("source", "b", start.Add(2*time.Minute)))
+
+	_, err := s.MergeNamespace("source", "dst")
+	must(t, err)
+	expectActiveTimer(t, s, &storage.Interval{Namespace: "dst", Name: "b (2)", Start: start.Add(2 * time.Minute)})
+	expectIntervals(t, s, "dst", time.Time{},
+		storage.Interval{Namespace: "dst", Name: "b (2)", Start: start, End: start.Add(time.Minute)},
+		storage.Interval{Namespace: "dst", Name: "a", Start: start.Add(time.Minute), End: start.Add(2 * time.Minute)},
+		storage.Interval{Namespace: "dst", Name: "b (2)", Start: start.Add(2 * time.Minute)},
+	)
+	expectIntervals(t, s, "source", time.Time{})
+}
+
+func testRenameNamespace(t *testing.T, newStorage Factory) {
+	s := newStorage(t, storage.SORT_NAME)
+	add(t, s, "wrk", "a")
+	add(t, s, "wrk/backend", "b")
+	must(t, s.SetPriority("wrk", "a", "B"))
+	must(t, s.SetPinned("wrk", "a", true))
+	add(t, s, "busy", "c")
+
+	expectError(t, s.RenameNamespace("wrk", "busy"), "RenameNamespace() to namespace with tasks")
+	expectError(t, s.RenameNamespace("missing", "new"), "RenameNamespace() of missing namespace")
+
+	must(t, s.RenameNamespace("wrk", "work"))
+	expectNames(t, s, "work", "a")
+	expectNames(t, s, "work/backend", "b")
+	expectPriority(t, s, "work", "a", "B")
+	expectCount(t, s, "busy", 1)
+
+	info, err := s.GetInfo("work", "a")
+	must(t, err)
+	if info.Pinned.IsZero() {
+		t.Errorf("GetInfo() after rename = %+v, expected pinned", info)
+	}
+}
+
+// Namespace without tasks can keep done and time logs, rename merges them
+func testRenameNamespaceToDoneLog(t *testing.T, newStorage Factory) {
+	s := newStorage(t, storage.SORT_NAME)
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	add(t, s, "work", "a")
+	must(t, s.StartTimer("work", "a", start))
+	_, err := s.StopTimer(start.Add(time.Minute))
+	must(t, err)
+	must(t, s.CompleteByName("work", "a", start.Add(time.Minute)))
+
+	add(t, s, "wrk", "a")
+	add(t, s, "wrk", "b")
+	must(t, s.StartTimer("wrk", "a", start.Add(2*time.Minute)))
+	_, err = s.StopTimer(start.Add(3 * time.Minute))
+	must(t, err)
+	must(t, s.CompleteByName("wrk", "b", start.Add(3*time.Minute)))
+
+	must(t, s.RenameNamespace("wrk", "work"))
+	expectNames(t, s, "work", "a")
+
+	done, err := s.GetDone("work", time.Time{})
+	must(t, err)
+	if len(done) != 2 {
+		t.Errorf("GetDone() after rename = %+v, expected done logs of both namespaces", done)
+	}
+
+	done, err = s.GetDone("wrk", time.Time{})
+	must(t, err)
+	if len(done) != 0 {
+		t.Errorf("GetDone() of renamed namespace = %+v, expected empty", done)
+	}
+
+	expectIntervals(t, s, "work", time.Time{},
+		storage.Interval{Namespace: "work", Name: "a", Start: start, End: start.Add(time.Minute)},
+		storage.Interval{Namespace: "work", Name: "a", Start: start.Add(2 * time.Minute), End: start.Add(3 * time.Minute)},
+	)
+}
+
+func testMergeNamespace(t *testing.T, newStorage Factory) {
+	s := newStorage(t, storage.SORT_NAME)
+	add(t, s, "src", "a")
+	add(t, s, "src", "b")
+	write(t, s, "src", "b", "from src\n")
+	add(t, s, "dst", "b")
+	must(t, s.CompleteByName("src", "a", time.Now()))
+	add(t, s, "src", "a")
+
+	renamed, err := s.MergeNamespace("src", "dst")
+	must(t, err)
+	if !reflect.DeepEqual(renamed, map[string]string{"b": "b (2)"}) {
+		t.Errorf("MergeNamespace() renamed %v, expected b to 'b (2)'", renamed)
+	}
+
+	expectNames(t, s, "dst", "a", "b", "b (2)")
+	expectContent(t, s, "dst", "b (2)", "from src\n")
+
+	done, err := s.GetDone("dst", time.Time{})
+	must(t, err)
+	if len(done) != 1 {
+		t.Errorf("GetDone() after merge = %+v, expected done log of src", done)
+	}
+
+	_, err = s.MergeNamespace("missing", "dst")
+	expectError(t, err, "MergeNamespace() of missing namespace")
+}
+
+// Time logs are found by names of tasks, so merge doesn't give time of one task to other with the same name
+func testMergeNamespaceTimeLogs(t *testing.T, newStorage Factory) {
+	s := newStorage(t, storage.SORT_NAME)
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	track := func(namespace string, name string, offset time.Duration) {
+		t.Helper()
+		must(t, s.StartTimer(namespace, name, start.Add(offset)))
+		_, err := s.StopTimer(start.Add(offset + time.Minute))
+		must(t, err)
+	}
+
+	add(t, s, "src", "a")
+	track("src", "a", 0)
+	must(t, s.CompleteByName("src", "a", start.Add(time.Minute)))
+	add(t, s, "dst", "a")
+	track("dst", "a", 2*time.Minute)
+
+	add(t, s, "dst", "b")
+	track("dst", "b", 4*time.Minute)
+	must(t, s.CompleteByName("dst", "b", start.Add(5*time.Minute)))
+	add(t, s, "src", "b")
+	track("src", "b", 6*time.Minute)
+
+	renamed, err := s.MergeNamespace("src", "dst")
+	must(t, err)
+	if !reflect.DeepEqual(renamed, map[string]string{"b": "b (2)"}) {
+		t.Errorf("MergeNamespace() renamed %v, expected b to 'b (2)'", renamed)
+	}
+
+	expectNames(t, s, "dst", "a", "b (2)")
+	expectIntervals(t, s, "dst", time.Time{},
+		storage.Interval{Namespace: "dst", Name: "a (2)", Start: start, End: start.Add(time.Minute)},
+		storage.Interval{Namespace: "dst", Name: "a", Start: start.Add(2 * time.Minute), End: start.Add(3 * time.Minute)},
+		storage.Interval{Namespace: "dst", Name: "b", Start: start.Add(4 * time.Minute), End: start.Add(5 * time.Minute)},
+		storage.Interval{Namespace: "dst", Name: "b (2)", Start: start.Add(6 * time.Minute), End: start.Add(7 * time.Minute)},
+	)
+
+	done, err := s.GetDone("dst", time.Time{})
+	must(t, err)
+	doneNames := map[string]bool{}
+	for _, task := range done {
+		doneNames[task.Name] = true
+	}
+	if !reflect.DeepEqual(doneNames, map[string]bool{"a (2)": true, "b": true}) {
+		t.Errorf("GetDone() after merge = %+v, expected 'a (2)' with its time log and 'b'", done)
+	}
+}
+
+func testDeleteNamespace(t *testing.T, newStorage Factory) {
+	s := newStorage(t, storage.SORT_NAME)
+	add(t, s, "work", "a")
+	add(t, s, "work/backend", "b")
+	add(t, s, "other", "c")
+
+	must(t, s.DeleteNamespace("work"))
+	expectCount(t, s, "work", 0)
+	expectNames(t, s, "work/backend", "b")
+	expectNames(t, s, "other", "c")
+
+	expectError(t, s.DeleteNamespace("missing"), "DeleteNamespace() of missing namespace")
+}
+
+func add(t *testing.T, s storage.TasksStorage, namespace string, name string) {
+	t.Helper()
+	must(t, s.Add(namespace, name))
+}
+
+func write(t *testing.T, s storage.TasksStorage, namespace string, name string, content string) {
+	t.Helper()
+	must(t, s.WriteByName(namespace, name, strings.NewReader(content)))
+}
+
+func expectNames(t *testing.T, s storage.TasksStorage, namespace string, expected ...string) {
+	t.Helper()
+
+	names, err := s.GetSorted(namespace)
+	must(t, err)
+
+	for i, name := range names {
+		names[i] = strings.ReplaceAll(name, "%2F", "/")
+	}
+
+	if len(names) != len(expected) || (len(names) > 0 && !reflect.DeepEqual(names, expected)) {
+		t.Errorf("GetSorted(%s) = %q, expected %q", namespace, names, expected)
+	}
+}
+
+func expectCount(t *testing.T, s storage.TasksStorage, namespace string, expected int) {
+	t.Helper()
+
+	count, err := s.Count(namespace)
+	if err != nil && expected == 0 {
+		return // namespace without tasks may not exist
+	}
+	must(t, err)
+
+	if count != expected {
+		t.Errorf("Count(%s) = %d, expected %d", namespace, count, expected)
+	}
+}
+
+func expectContent(t *testing.T, s storage.TasksStorage, namespace string, name string, expected string) {
+	t.Helper()
+
+	content, err := s.GetContentByName(namespace, name)
+	must(t, err)
+
+	if string(content) != expected {
+		t.Errorf("GetContentByName(%s, %s) = %q, expected %q", namespace, name, content, expected)
+	}
+}
+
+func expectPriority(t *testing.T, s storage.TasksStorage, namespace string, name string, expected string) {
+	t.Helper()
+
+	info, err := s.GetInfo(namespace, name)
+	must(t, err)
+
+	if info.Priority != expected {
+		t.Errorf("GetInfo(%s, %s).Priority = %q, expected %q", namespace, name, info.Priority, expected)
+	}
+}
+
+func expectActiveTimer(t *testing.T, s storage.TasksStorage, expected *storage.Interval) {
+	t.Helper()
+
+	active, err := s.GetActiveTimer()
+	must(t, err)
+
+	if (active == nil) != (expected == nil) || (active != nil && !equalIntervals([]storage.Interval{*active}, []storage.Interval{*expected})) {
+		t.Errorf("GetActiveTimer() = %+v, expected %+v", active, expected)
+	}
+}
+
+func expectIntervals(t *testing.T, s storage.TasksStorage, namespace string, since time.Time, expected ...storage.Interval) {
+	t.Helper()
+
+	intervals, err := s.GetIntervals(namespace, since)
+	must(t, err)
+
+	if !equalIntervals(intervals, expected) {
+		t.Errorf("GetIntervals(%q, %s) = %+v, expected %+v", namespace, since, intervals, expected)
+	}
+}
+
+func equalIntervals(a []storage.Interval, b []storage.Interval) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].Namespace != b[i].Namespace || a[i].Name != b[i].Name || !a[i].Start.Equal(b[i].Start) || !a[i].End.Equal(b[i].End) {
+			return false
+		}
+	}
+	return true
+}
+
+func expectError(t *testing.T, err error, format string, args ...any) {
+	t.Helper()
+
+	if err == nil {
+		t.Errorf(format+" expected to fail", args...)
+	}
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+}
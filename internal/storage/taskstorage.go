@@ -1,17 +1,267 @@
 package storage
 
-import "io"
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Sort orders of tasks, recently updated first by default
+const SORT_UPDATED = "updated"
+const SORT_CREATED = "created"
+const SORT_NAME = "name"
+const SORT_LINES = "lines"
+const SORT_PRIORITY = "priority"
+
+const SORT_REVERSE = "reverse"
+
+var SORTS = []string{SORT_UPDATED, SORT_CREATED, SORT_NAME, SORT_LINES, SORT_PRIORITY}
+
+// Order of tasks: recently updated or created first, names from A to Z, tasks with more lines first
+// or highest priority first, Reverse inverts order
+type SortOrder struct {
+	By      string
+	Reverse bool
+}
+
+// Parses order like 'name' or 'name reverse', empty order is recently updated first
+func ParseSortOrder(s string) (SortOrder, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return SortOrder{By: SORT_UPDATED}, nil
+	}
+
+	order := SortOrder{By: fields[0]}
+	if !slices.Contains(SORTS, order.By) {
+		return SortOrder{}, fmt.Errorf("Unknown sort '%s', expected one of: %s", order.By, strings.Join(SORTS, ", "))
+	}
+
+	switch {
+	case len(fields) == 2 && fields[1] == SORT_REVERSE:
+		order.Reverse = true
+	case len(fields) > 1:
+		return SortOrder{}, fmt.Errorf("Wrong sort '%s', expected sort name and optional '%s'", s, SORT_REVERSE)
+	}
+
+	return order, nil
+}
+
+func (o SortOrder) String() string {
+	if o.Reverse {
+		return o.By + " " + SORT_REVERSE
+	}
+	return o.By
+}
+
+// Returns order of namespace from sorts, or default order if namespace has no own order
+func namespaceOrder(namespace string, sortBy string, reverse bool, sorts map[string]SortOrder) SortOrder {
+	order, found := sorts[namespace]
+	if found {
+		return order
+	}
+	return SortOrder{By: sortBy, Reverse: reverse}
+}
 
 type TasksStorage interface {
 	GetNamespaces() ([]string, error)
 	Count(namespace string) (int, error)
 	GetSorted(namespace string) ([]string, error)
+	GetSortedBy(namespace string, order SortOrder) ([]string, error)
 	GetContentByIndex(namespace string, index int) ([]byte, error)
 	GetContentByName(namespace string, name string) ([]byte, error)
 	GetNameByIndex(namespace string, index int) (string, error)
 	DeleteByIndexes(namespace string, indexes []int) error
+	CompleteByIndexes(namespace string, indexes []int) error
+	CompleteByName(namespace string, name string, doneAt time.Time) error
+	GetDone(namespace string, since time.Time) ([]DoneTask, error)
 	WriteByName(namespace string, name string, r io.Reader) error
 	WriteByIndex(namespace string, index int, r io.Reader) error
 	Add(namespace string, name string) error
 	CountLines(namespace string, name string) (int, error)
+	GetInfo(namespace string, name string) (TaskInfo, error)
+	SetTimes(namespace string, name string, info TaskInfo) error
+	SetPriority(namespace string, name string, priority string) error
+	SetPinned(namespace string, name string, pinned bool) error
+	SetRank(namespace string, name string, rank int) error
+	GetRecurrence(namespace string, name string) (*Recurrence, error)
+	SetRecurrence(namespace string, name string, r *Recurrence) error
+	RenameNamespace(old string, new string) error
+	MergeNamespace(src string, dst string) (map[string]string, error)
+	DeleteNamespace(namespace string) error
+	StartTimer(namespace string, name string, start time.Time) error
+	StopTimer(end time.Time) (*Interval, error)
+	GetActiveTimer() (*Interval, error)
+	GetIntervals(namespace string, since time.Time) ([]Interval, error)
+}
+
+const PREVIEW_LENGTH = 80
+
+// Timestamps of task, zero if backend doesn't track it, first line of content,
+// priority letter from A (highest) to Z, empty if not set, time of pin, zero if not pinned,
+// and position in manual order starting from 1, zero if task is not ordered
+type TaskInfo struct {
+	Created  time.Time
+	Updated  time.Time
+	Read     time.Time
+	Preview  string
+	Priority string
+	Pinned   time.Time
+	Rank     int
+}
+
+func preview(content []byte) string {
+	line, _, _ := strings.Cut(string(content), "\n")
+	runes := []rune(line)
+	if len(runes) > PREVIEW_LENGTH {
+		return string(runes[:PREVIEW_LENGTH])
+	}
+	return line
+}
+
+// Recurrence of task, after task completed its fresh copy with Template content is created
+type Recurrence struct {
+	Rule     string
+	Due      time.Time
+	Template []byte
+}
+
+// Completed task, GetDone with empty namespace returns done tasks from all namespaces.
+// Created is time of creation, file systems keep only time of last modification
+type DoneTask struct {
+	Namespace string
+	Name      string
+	Created   time.Time
+	DoneAt    time.Time
+}
+
+// Work on task, End is zero while timer is running. Only one timer runs at a time,
+// StartTimer stops running timer, StopTimer returns nil if no timer runs.
+// GetIntervals returns intervals ended after since with running one, from all namespaces if namespace is empty
+type Interval struct {
+	Namespace string
+	Name      string
+	Start     time.Time
+	End       time.Time
+}
+
+// Returns duration of interval, running interval lasts until now
+func (i Interval) Duration(now time.Time) time.Duration {
+	if i.End.IsZero() {
+		return now.Sub(i.Start)
+	}
+	return i.End.Sub(i.Start)
+}
+
+func sortIntervals(intervals []Interval) {
+	sort.SliceStable(intervals, func(i, j int) bool {
+		return intervals[i].Start.Before(intervals[j].Start)
+	})
+}
+
+// Task deleted by backend with soft delete, Info contains timestamps from time of deletion
+type DeletedTask struct {
+	Namespace  string
+	Name       string
+	LinesCount int
+	Info       TaskInfo
+	Deleted    time.Time
+}
+
+// Implemented by backends which keep deleted tasks
+type TrashStorage interface {
+	GetDeleted(namespace string) ([]DeletedTask, error)
+}
+
+// Fields of task used by sort orders
+type sortItem struct {
+	name     string
+	created  time.Time
+	updated  time.Time
+	lines    int
+	priority string
+}
+
+// Sorts items by order, ties are sorted by name
+func sortItems(items []sortItem, order SortOrder) {
+	less := func(a sortItem, b sortItem) bool {
+		switch order.By {
+		case SORT_CREATED:
+			if !a.created.Equal(b.created) {
+				return a.created.After(b.created)
+			}
+		case SORT_LINES:
+			if a.lines != b.lines {
+				return a.lines > b.lines
+			}
+		case SORT_PRIORITY:
+			if a.priority != b.priority {
+				return b.priority == "" || (a.priority != "" && a.priority < b.priority)
+			}
+			if !a.updated.Equal(b.updated) {
+				return a.updated.After(b.updated)
+			}
+		case SORT_NAME:
+		default:
+			if !a.updated.Equal(b.updated) {
+				return a.updated.After(b.updated)
+			}
+		}
+		return a.name < b.name
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if order.Reverse {
+			return less(items[j], items[i])
+		}
+		return less(items[i], items[j])
+	})
+}
+
+func itemNames(items []sortItem) []string {
+	names := make([]string, len(items))
+	for i, item := range items {
+		names[i] = item.name
+	}
+	return names
+}
+
+// Moves pinned tasks to start in order of pinning, then ranked tasks in order of ranks,
+// order of other tasks is kept, so new tasks are listed after manually ordered ones
+func sortPinnedAndRanked(tasks []string, pins map[string]time.Time, ranks map[string]int) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		iPin, iPinned := pins[tasks[i]]
+		jPin, jPinned := pins[tasks[j]]
+
+		if iPinned || jPinned {
+			if iPinned && jPinned {
+				return iPin.Before(jPin)
+			}
+			return iPinned
+		}
+
+		iRank, jRank := ranks[tasks[i]], ranks[tasks[j]]
+		if iRank == 0 || jRank == 0 {
+			return iRank != 0 && jRank == 0
+		}
+		return iRank < jRank
+	})
+}
+
+func sortDone(tasks []DoneTask) {
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].DoneAt.After(tasks[j].DoneAt)
+	})
+}
+
+// Returns name with suffix ' (N)' if name is taken, marks result as taken
+func uniqueName(name string, taken map[string]bool) string {
+	result := name
+	for n := 2; taken[result]; n++ {
+		result = fmt.Sprintf("%s (%d)", name, n)
+	}
+	taken[result] = true
+	return result
 }
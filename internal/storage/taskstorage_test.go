@@ -0,0 +1,32 @@
+package storage_test
+
+import (
+	"testing"
+
+	storage "github.com/thek4n/t/internal/storage"
+)
+
+func TestParseSortOrder(t *testing.T) {
+	cases := map[string]storage.SortOrder{
+		"":                 {By: storage.SORT_UPDATED},
+		"name":             {By: storage.SORT_NAME},
+		" lines  reverse ": {By: storage.SORT_LINES, Reverse: true},
+	}
+
+	for input, expected := range cases {
+		order, err := storage.ParseSortOrder(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if order != expected {
+			t.Errorf("ParseSortOrder(%q) = %+v, expected %+v", input, order, expected)
+		}
+	}
+
+	for _, input := range []string{"size", "name backwards", "name reverse reverse"} {
+		_, err := storage.ParseSortOrder(input)
+		if err == nil {
+			t.Errorf("ParseSortOrder(%q) expected to fail", input)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const (
+	keyUp        = "up"
+	keyDown      = "down"
+	keyLeft      = "left"
+	keyRight     = "right"
+	keyEnter     = "enter"
+	keyEscape    = "esc"
+	keyBackspace = "backspace"
+	keyTab       = "tab"
+)
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("Error run stty: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func terminalSize() (int, int) {
+	size, err := stty("size")
+	if err != nil {
+		return 24, 80
+	}
+
+	var rows, cols int
+	_, err = fmt.Sscanf(size, "%d %d", &rows, &cols)
+	if err != nil || rows < 5 || cols < 30 {
+		return 24, 80
+	}
+	return rows, cols
+}
+
+// Enters raw mode and alternate screen, returns function restoring terminal
+func enterFullScreen() (func(), error) {
+	state, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = stty("raw", "-echo")
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Print("\033[?1049h\033[?25l")
+
+	return func() {
+		fmt.Print("\033[?25h\033[?1049l")
+		stty(state)
+	}, nil
+}
+
+func readKey(r *bufio.Reader) (string, error) {
+	c, _, err := r.ReadRune()
+	if err != nil {
+		return "", err
+	}
+
+	switch c {
+	case '\r', '\n':
+		return keyEnter, nil
+	case '\t':
+		return keyTab, nil
+	case 127, '\b':
+		return keyBackspace, nil
+	case 27:
+		return readEscapeSequence(r)
+	}
+
+	return string(c), nil
+}
+
+func readEscapeSequence(r *bufio.Reader) (string, error) {
+	if r.Buffered() < 2 {
+		return keyEscape, nil
+	}
+
+	prefix, _ := r.ReadByte()
+	code, _ := r.ReadByte()
+	if prefix != '[' && prefix != 'O' {
+		return keyEscape, nil
+	}
+
+	switch code {
+	case 'A':
+		return keyUp, nil
+	case 'B':
+		return keyDown, nil
+	case 'C':
+		return keyRight, nil
+	case 'D':
+		return keyLeft, nil
+	}
+	return keyEscape, nil
+}
+
+// Cuts or pads string to exactly width runes
+func fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+
+	runes := []rune(strings.ReplaceAll(s, "\t", "    "))
+	if len(runes) > width {
+		return string(runes[:width-1]) + "…"
+	}
+	return string(runes) + strings.Repeat(" ", width-len(runes))
+}
@@ -0,0 +1,391 @@
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	handlers "github.com/thek4n/t/internal/handlers"
+	storage "github.com/thek4n/t/internal/storage"
+)
+
+const SIDEBAR_WIDTH = 24
+const HELP_LINE = "a add  d done  e edit  m move  / search  tab switch  q quit"
+
+type pane int
+
+const (
+	namespacesPane pane = iota
+	tasksPane
+)
+
+type model struct {
+	s          storage.TasksStorage
+	keys       *bufio.Reader
+	namespaces []string
+	namespace  string
+	tasks      []handlers.TaskView
+	visible    []int // indexes of tasks matching filter
+	cursor     int
+	nsCursor   int
+	focus      pane
+	filter     string
+	status     string
+}
+
+// Runs keyboard driven full screen interface until 'q' pressed
+func Run(namespace string, s storage.TasksStorage) error {
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		return fmt.Errorf("tui requires a terminal")
+	}
+
+	restore, err := enterFullScreen()
+	if err != nil {
+		return err
+	}
+	defer func() { restore() }()
+
+	m := &model{s: s, keys: bufio.NewReader(os.Stdin), focus: tasksPane}
+	m.load(namespace)
+
+	for {
+		m.render("")
+
+		key, err := readKey(m.keys)
+		if err != nil {
+			return err
+		}
+
+		if key == "q" {
+			return nil
+		}
+
+		if key == "e" && m.focus == tasksPane {
+			restore()
+			m.edit()
+			restore, err = enterFullScreen()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		m.handle(key)
+	}
+}
+
+func (m *model) load(namespace string) {
+	m.namespace = namespace
+	m.status = ""
+
+	namespaces, err := m.s.GetNamespaces()
+	if err != nil {
+		m.status = err.Error()
+	}
+
+	if !contains(namespaces, namespace) {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	m.namespaces = namespaces
+
+	for i, ns := range namespaces {
+		if ns == namespace {
+			m.nsCursor = i
+		}
+	}
+
+	m.tasks, err = handlers.ListTasks(namespace, m.s)
+	if err != nil {
+		m.tasks = nil
+		m.status = err.Error()
+	}
+
+	m.applyFilter()
+}
+
+func (m *model) applyFilter() {
+	m.visible = m.visible[:0]
+	for i, tv := range m.tasks {
+		if strings.Contains(strings.ToLower(tv.FormattedName), strings.ToLower(m.filter)) {
+			m.visible = append(m.visible, i)
+		}
+	}
+
+	if m.cursor >= len(m.visible) {
+		m.cursor = len(m.visible) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+}
+
+// Returns storage index of selected task, 0 if nothing selected
+func (m *model) selectedIndex() int {
+	if len(m.visible) == 0 {
+		return 0
+	}
+	return m.visible[m.cursor] + 1
+}
+
+func (m *model) handle(key string) {
+	switch key {
+	case keyTab, "h", "l", keyLeft, keyRight:
+		if m.focus == tasksPane {
+			m.focus = namespacesPane
+		} else {
+			m.focus = tasksPane
+		}
+
+	case "j", keyDown:
+		m.moveCursor(1)
+
+	case "k", keyUp:
+		m.moveCursor(-1)
+
+	case keyEnter:
+		if m.focus == namespacesPane {
+			m.focus = tasksPane
+		}
+
+	case "a":
+		name, ok := m.prompt("add: ")
+		if ok && name != "" {
+			m.report(handlers.AddTask(m.namespace, name, m.s))
+		}
+
+	case "d":
+		index := m.selectedIndex()
+		if m.focus == tasksPane && index > 0 {
+			m.report(handlers.CompleteTasksByIndexes(m.namespace, []int{index}, m.s))
+		}
+
+	case "m":
+		index := m.selectedIndex()
+		if m.focus != tasksPane || index == 0 {
+			return
+		}
+		destination, ok := m.prompt("move to namespace: ")
+		if ok && destination != "" {
+			m.report(handlers.MoveTaskByIndex(m.namespace, index, destination, m.s))
+		}
+
+	case "/":
+		filter, ok := m.prompt("search: ")
+		if ok {
+			m.filter = filter
+			m.cursor = 0
+			m.applyFilter()
+		}
+
+	case keyEscape:
+		m.filter = ""
+		m.applyFilter()
+	}
+}
+
+func (m *model) moveCursor(delta int) {
+	if m.focus == tasksPane {
+		m.cursor = clamp(m.cursor+delta, 0, len(m.visible)-1)
+		return
+	}
+
+	m.nsCursor = clamp(m.nsCursor+delta, 0, len(m.namespaces)-1)
+	m.cursor = 0
+	m.load(m.namespaces[m.nsCursor])
+}
+
+func (m *model) edit() {
+	index := m.selectedIndex()
+	if index == 0 {
+		return
+	}
+
+	err := handlers.EditTaskByIndex(m.namespace, index, m.s)
+	m.report(err)
+}
+
+// Reloads namespace after modification and shows error in status line
+func (m *model) report(err error) {
+	m.load(m.namespace)
+	if err != nil {
+		m.status = err.Error()
+	}
+}
+
+func (m *model) prompt(label string) (string, bool) {
+	var input []rune
+
+	for {
+		m.render(label + string(input) + "_")
+
+		key, err := readKey(m.keys)
+		if err != nil {
+			return "", false
+		}
+
+		switch key {
+		case keyEnter:
+			return strings.TrimSpace(string(input)), true
+		case keyEscape:
+			return "", false
+		case keyBackspace:
+			if len(input) > 0 {
+				input = input[:len(input)-1]
+			}
+		case keyTab, keyUp, keyDown, keyLeft, keyRight:
+		default:
+			input = append(input, []rune(key)...)
+		}
+	}
+}
+
+func (m *model) render(promptLine string) {
+	rows, cols := terminalSize()
+	bodyHeight := rows - 2
+	mainWidth := cols - SIDEBAR_WIDTH - 1
+	listHeight := bodyHeight / 2
+	previewHeight := bodyHeight - listHeight - 1
+
+	sidebar := m.sidebarLines(bodyHeight)
+	main := append(m.taskLines(listHeight, mainWidth), "\033[2m"+strings.Repeat("─", mainWidth)+"\033[0m")
+	main = append(main, m.previewLines(previewHeight, mainWidth)...)
+
+	var b strings.Builder
+	b.WriteString("\033[H\033[2J")
+
+	title := fmt.Sprintf(" t: %s", m.namespace)
+	if m.filter != "" {
+		title += fmt.Sprintf("  (search: %s)", m.filter)
+	}
+	b.WriteString("\033[1;34m" + fit(title, cols) + "\033[0m\r\n")
+
+	for i := 0; i < bodyHeight; i++ {
+		b.WriteString(sidebar[i])
+		b.WriteString("\033[2m│\033[0m")
+		if i < len(main) {
+			b.WriteString(main[i])
+		}
+		b.WriteString("\r\n")
+	}
+
+	statusLine := HELP_LINE
+	if m.status != "" {
+		statusLine = "\033[31m" + fit(m.status, cols) + "\033[0m"
+	}
+	if promptLine != "" {
+		statusLine = promptLine
+	}
+	b.WriteString(fit(statusLine, cols))
+
+	fmt.Print(b.String())
+}
+
+func (m *model) sidebarLines(height int) []string {
+	lines := make([]string, height)
+	offset := scrollOffset(m.nsCursor, height)
+
+	for i := range lines {
+		nsIndex := offset + i
+		if nsIndex >= len(m.namespaces) {
+			lines[i] = fit("", SIDEBAR_WIDTH)
+			continue
+		}
+
+		ns := m.namespaces[nsIndex]
+		count, err := m.s.Count(ns)
+		line := fit(fmt.Sprintf(" %s (%d)", ns, count), SIDEBAR_WIDTH)
+		if err != nil {
+			line = fit(fmt.Sprintf(" %s (-)", ns), SIDEBAR_WIDTH)
+		}
+
+		lines[i] = highlight(line, nsIndex == m.nsCursor, m.focus == namespacesPane)
+	}
+
+	return lines
+}
+
+func (m *model) taskLines(height int, width int) []string {
+	lines := make([]string, 0, height)
+	offset := scrollOffset(m.cursor, height)
+
+	for i := 0; i < height; i++ {
+		visibleIndex := offset + i
+		if visibleIndex >= len(m.visible) {
+			lines = append(lines, "")
+			continue
+		}
+
+		taskIndex := m.visible[visibleIndex]
+		tv := m.tasks[taskIndex]
+		name := tv.FormattedName
+		if tv.Pinned {
+			name = handlers.PIN_MARKER + " " + name
+		}
+		line := fit(fmt.Sprintf(" [%d] %s (%s)%s", taskIndex+1, name, tv.FormattedLinesCount, tv.FormattedRecurrence), width)
+
+		lines = append(lines, highlight(line, visibleIndex == m.cursor, m.focus == tasksPane))
+	}
+
+	return lines
+}
+
+func (m *model) previewLines(height int, width int) []string {
+	index := m.selectedIndex()
+	if index == 0 {
+		return nil
+	}
+
+	content, err := m.s.GetContentByName(m.namespace, m.tasks[index-1].Name)
+	if err != nil {
+		return []string{fit(" "+err.Error(), width)}
+	}
+
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+
+	for i, line := range lines {
+		lines[i] = fit(" "+line, width)
+	}
+	return lines
+}
+
+func highlight(line string, selected bool, focused bool) string {
+	switch {
+	case selected && focused:
+		return "\033[7m" + line + "\033[0m"
+	case selected:
+		return "\033[1m" + line + "\033[0m"
+	}
+	return line
+}
+
+func scrollOffset(cursor int, height int) int {
+	if cursor < height {
+		return 0
+	}
+	return cursor - height + 1
+}
+
+func clamp(value int, min int, max int) int {
+	if value > max {
+		value = max
+	}
+	if value < min {
+		value = min
+	}
+	return value
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
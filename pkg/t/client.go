@@ -0,0 +1,295 @@
+// Package t is library interface of t task tracker.
+//
+// Client wraps storage of tasks, its operations return structured results
+// and its renderers write the same text as command line tool to io.Writer:
+//
+//	c := t.New(t.NewFSStorage(dir))
+//	err := c.Add("work", "fix bug 211")
+//	tasks, err := c.Tasks("work")
+//	err = c.RenderTasks(&buf, "work")
+package t
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	handlers "github.com/thek4n/t/internal/handlers"
+	storage "github.com/thek4n/t/internal/storage"
+)
+
+type (
+	Storage       = storage.TasksStorage
+	TaskInfo      = storage.TaskInfo
+	DoneTask      = storage.DoneTask
+	Interval      = storage.Interval
+	Recurrence    = storage.Recurrence
+	TaskView      = handlers.TaskView
+	NamespaceView = handlers.NamespaceView
+	ImportResult  = handlers.ImportResult
+	Stats         = handlers.Stats
+	SortOrder     = storage.SortOrder
+)
+
+// Sort orders of tasks
+const (
+	SortUpdated  = storage.SORT_UPDATED
+	SortCreated  = storage.SORT_CREATED
+	SortName     = storage.SORT_NAME
+	SortLines    = storage.SORT_LINES
+	SortPriority = storage.SORT_PRIORITY
+)
+
+// Color modes of renderers, colors are disabled by default
+const (
+	ColorAuto   = handlers.COLOR_AUTO
+	ColorAlways = handlers.COLOR_ALWAYS
+	ColorNever  = handlers.COLOR_NEVER
+)
+
+// Returns storage keeping tasks as files in directory per namespace, same as command line tool
+func NewFSStorage(dir string) Storage {
+	return &storage.FSTasksStorage{TBaseDir: dir, Sort: storage.SORT_UPDATED}
+}
+
+// Returns storage keeping tasks in memory, for tests and short-lived embedding
+func NewMemoryStorage() Storage {
+	return &storage.MemoryTasksStorage{Sort: storage.SORT_UPDATED}
+}
+
+// Wraps storage of data directory encrypted by 't encrypt', contents and names are decrypted by key of passphrase
+func OpenEncrypted(s Storage, dir string, passphrase string) (Storage, error) {
+	header, err := storage.ReadEncryptionHeader(dir)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, fmt.Errorf("Tasks in '%s' are not encrypted", dir)
+	}
+
+	key, err := header.DeriveKey(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewEncryptedStorage(s, key, header.Names)
+}
+
+// Sets colors of all renderers, mode 'auto' checks that stdout is terminal
+func SetColorMode(mode string) error {
+	return handlers.SetColorMode(mode)
+}
+
+// Sets directory with hooks run around operations, hooks are disabled by default
+func SetHooksDir(dir string) {
+	handlers.HooksDir = dir
+}
+
+type Client struct {
+	Storage Storage
+}
+
+func New(s Storage) *Client {
+	return &Client{Storage: s}
+}
+
+// Returns namespaces sorted as tree with counts of tasks
+func (c *Client) Namespaces() ([]NamespaceView, error) {
+	return handlers.NamespaceTree(c.Storage)
+}
+
+// Returns tasks of namespace in order of indexes, first index is 1
+func (c *Client) Tasks(namespace string) ([]TaskView, error) {
+	err := handlers.ValidateNamespace(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return handlers.ListTasks(namespace, c.Storage)
+}
+
+// Returns tasks sorted by order, indexes are from default order
+func (c *Client) TasksSorted(namespace string, order SortOrder) ([]TaskView, error) {
+	err := handlers.ValidateNamespace(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return handlers.ListTasksSorted(namespace, order, c.Storage)
+}
+
+func (c *Client) Content(namespace string, index int) ([]byte, error) {
+	return c.Storage.GetContentByIndex(namespace, index)
+}
+
+func (c *Client) Add(namespace string, name string) error {
+	err := handlers.ValidateNamespace(namespace)
+	if err != nil {
+		return err
+	}
+
+	if name == "" {
+		return fmt.Errorf("Task name is empty")
+	}
+
+	return handlers.AddTask(namespace, name, c.Storage)
+}
+
+// Replaces content of task
+func (c *Client) Write(namespace string, index int, r io.Reader) error {
+	return c.Storage.WriteByIndex(namespace, index, r)
+}
+
+func (c *Client) Complete(namespace string, indexes ...int) error {
+	return handlers.CompleteTasksByIndexes(namespace, indexes, c.Storage)
+}
+
+func (c *Client) Delete(namespace string, indexes ...int) error {
+	return handlers.DeleteTasksByIndexes(namespace, indexes, c.Storage)
+}
+
+func (c *Client) Move(namespace string, index int, destination string) error {
+	err := handlers.ValidateNamespace(destination)
+	if err != nil {
+		return err
+	}
+
+	return handlers.MoveTaskByIndex(namespace, index, destination, c.Storage)
+}
+
+// Sets priority letter from A to Z, 'none' removes priority
+func (c *Client) SetPriority(namespace string, index int, priority string) error {
+	return handlers.SetPriorityByIndex(namespace, index, priority, c.Storage)
+}
+
+// Pinned tasks are listed first in order of pinning
+func (c *Client) Pin(namespace string, indexes ...int) error {
+	return handlers.PinTasksByIndexes(namespace, indexes, true, c.Storage)
+}
+
+func (c *Client) Unpin(namespace string, indexes ...int) error {
+	return handlers.PinTasksByIndexes(namespace, indexes, false, c.Storage)
+}
+
+// Moves task to position in list, manual order is kept after edits
+func (c *Client) Order(namespace string, index int, position int) error {
+	return handlers.OrderTaskByIndex(namespace, index, position, c.Storage)
+}
+
+// Sets recurrence rule like 'weekly mon', 'none' removes recurrence
+func (c *Client) SetRecurrence(namespace string, index int, rule string) error {
+	return handlers.SetRecurrenceByIndex(namespace, index, rule, c.Storage)
+}
+
+// Starts timer of task, running timer of other task is stopped
+func (c *Client) Start(namespace string, index int) error {
+	return handlers.StartTimerByIndex(namespace, index, c.Storage)
+}
+
+// Returns stopped interval, nil if no timer runs
+func (c *Client) Stop() (*Interval, error) {
+	return c.Storage.StopTimer(time.Now())
+}
+
+// Returns work intervals ended after since with running one, from all namespaces if namespace is empty
+func (c *Client) Intervals(namespace string, since time.Time) ([]Interval, error) {
+	return c.Storage.GetIntervals(namespace, since)
+}
+
+// Returns tasks completed after since, from all namespaces if namespace is empty
+func (c *Client) Done(namespace string, since time.Time) ([]DoneTask, error) {
+	return c.Storage.GetDone(namespace, since)
+}
+
+func (c *Client) RenameNamespace(old string, new string) error {
+	return handlers.RenameNamespace(old, new, c.Storage)
+}
+
+// Returns new names of tasks renamed because of name collisions
+func (c *Client) MergeNamespaces(src string, dst string) (map[string]string, error) {
+	return handlers.MergeNamespaces(src, dst, c.Storage)
+}
+
+func (c *Client) DeleteNamespace(namespace string, force bool) error {
+	return handlers.DeleteNamespace(namespace, force, c.Storage)
+}
+
+func (c *Client) RenderTasks(w io.Writer, namespace string) error {
+	return handlers.ShowTasks(w, namespace, c.Storage)
+}
+
+func (c *Client) RenderTasksSorted(w io.Writer, namespace string, order SortOrder) error {
+	return handlers.ShowTasksSorted(w, namespace, order, c.Storage)
+}
+
+// Renders tasks of namespace and of its child namespaces
+func (c *Client) RenderTasksWithChildren(w io.Writer, namespace string) error {
+	return handlers.ShowTasksWithChildren(w, namespace, storage.SortOrder{}, c.Storage)
+}
+
+func (c *Client) RenderAllTasks(w io.Writer) error {
+	return handlers.ShowAllTasksFromAllNamespaces(w, c.Storage)
+}
+
+// Renders tasks of namespaces by text/template format, fields are fields of TaskView
+func (c *Client) RenderFormatted(w io.Writer, namespaces []string, format string) error {
+	return handlers.ShowTasksFormatted(w, namespaces, format, storage.SortOrder{}, c.Storage)
+}
+
+func (c *Client) RenderContent(w io.Writer, namespace string, index int) error {
+	return handlers.ShowTaskContentByIndex(w, namespace, index, c.Storage)
+}
+
+func (c *Client) RenderNamespaces(w io.Writer) error {
+	return handlers.ShowNamespaces(w, c.Storage)
+}
+
+// Returns statistics of namespace with its child namespaces, of all namespaces if namespace is empty,
+// tasks are counted by "day" or "week"
+func (c *Client) Stats(namespace string, period string) (Stats, error) {
+	return handlers.CollectStats(namespace, period, time.Now(), c.Storage)
+}
+
+func (c *Client) RenderStats(w io.Writer, namespace string, period string) error {
+	return handlers.ShowStats(w, namespace, period, c.Storage)
+}
+
+// Renders time tracked after since grouped by "task", "ns" or "day"
+func (c *Client) RenderTime(w io.Writer, namespace string, since time.Time, by string) error {
+	return handlers.ShowTimeReport(w, namespace, since, by, c.Storage)
+}
+
+func (c *Client) RenderDoneLog(w io.Writer, namespace string, since time.Time) error {
+	return handlers.ShowDoneLog(w, namespace, since, c.Storage)
+}
+
+func (c *Client) ExportMarkdown(w io.Writer, namespaces []string) error {
+	return handlers.ExportMarkdown(w, namespaces, c.Storage)
+}
+
+func (c *Client) ExportTodoTxt(w io.Writer, namespaces []string) error {
+	return handlers.ExportTodoTxt(w, namespaces, c.Storage)
+}
+
+func (c *Client) ExportIcs(w io.Writer, namespaces []string) error {
+	return handlers.ExportIcs(w, namespaces, c.Storage)
+}
+
+func (c *Client) ExportCsv(w io.Writer, namespaces []string, includeDeleted bool) error {
+	return handlers.ExportCsv(w, namespaces, includeDeleted, c.Storage)
+}
+
+func (c *Client) ImportMarkdown(r io.Reader, namespace string) (ImportResult, error) {
+	return handlers.ImportMarkdown(r, namespace, c.Storage)
+}
+
+func (c *Client) ImportTodoTxt(r io.Reader, namespace string) (ImportResult, error) {
+	return handlers.ImportTodoTxt(r, namespace, c.Storage)
+}
+
+func (c *Client) ImportIcs(r io.Reader, namespace string) (ImportResult, error) {
+	return handlers.ImportIcs(r, namespace, c.Storage)
+}
+
+func (c *Client) ImportTaskwarrior(r io.Reader, namespace string) (ImportResult, error) {
+	return handlers.ImportTaskwarrior(r, namespace, c.Storage)
+}
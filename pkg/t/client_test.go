@@ -0,0 +1,249 @@
+package t
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	storage "github.com/thek4n/t/internal/storage"
+)
+
+func TestClient(t *testing.T) {
+	c := New(NewFSStorage(t.TempDir()))
+
+	for _, name := range []string{"fix bug 211", "a/b"} {
+		err := c.Add("work/backend", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := c.Write("work/backend", 1, strings.NewReader("details\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, err := c.Tasks("work/backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 2 || tasks[1].Index != 2 {
+		t.Fatalf("unexpected tasks %+v", tasks)
+	}
+
+	namespaces, err := c.Namespaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(namespaces) != 2 || namespaces[0].Namespace != "work" || namespaces[0].Total != 2 || namespaces[1].Count != 2 {
+		t.Errorf("unexpected namespaces %+v", namespaces)
+	}
+
+	var buf bytes.Buffer
+	err = c.RenderNamespaces(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "work (2)\n  backend (2)\n" {
+		t.Errorf("unexpected namespaces output %q", buf.String())
+	}
+
+	buf.Reset()
+	err = c.RenderFormatted(&buf, []string{"work/backend"}, "{{.Index}} {{.FormattedName}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "a/b") || strings.Count(buf.String(), "\n") != 2 {
+		t.Errorf("unexpected formatted output %q", buf.String())
+	}
+
+	err = c.Add("../etc", "x")
+	if err == nil {
+		t.Errorf("expected error for invalid namespace")
+	}
+}
+
+func TestClientRendersWithoutColors(t *testing.T) {
+	c := New(NewFSStorage(t.TempDir()))
+
+	err := c.Add("def", "task")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	err = c.RenderTasks(&buf, "def")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != "# def\n[1] task (-)\n" {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+}
+
+func TestClientTimer(t *testing.T) {
+	c := New(NewMemoryStorage())
+
+	for _, name := range []string{"a", "b"} {
+		err := c.Add("def", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := c.Start("def", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	err = c.RenderTasks(&buf, "def")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "[tracking 0m]") {
+		t.Errorf("unexpected output with running timer %q", buf.String())
+	}
+
+	err = c.Complete("def", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stopped, err := c.Stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stopped != nil {
+		t.Errorf("timer of done task expected to be stopped, got %+v", stopped)
+	}
+
+	intervals, err := c.Intervals("", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(intervals) != 1 || intervals[0].End.IsZero() {
+		t.Errorf("unexpected intervals %+v", intervals)
+	}
+}
+
+func TestClientStats(t *testing.T) {
+	c := New(NewMemoryStorage())
+
+	for _, name := range []string{"a", "b"} {
+		err := c.Add("work", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := c.Add("home", "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Complete("work", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := c.Stats("work", "week")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	last := stats.Periods[len(stats.Periods)-1]
+	if stats.OpenTasksCount != 1 || stats.DoneCount != 1 || last.Created != 2 || last.Done != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	_, err = c.Stats("", "month")
+	if err == nil {
+		t.Errorf("expected error for unknown period")
+	}
+}
+
+func TestOpenEncrypted(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := OpenEncrypted(NewFSStorage(dir), dir, "secret")
+	if err == nil {
+		t.Fatal("expected error on not encrypted directory")
+	}
+
+	header, _, err := storage.NewEncryptionHeader("secret", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = storage.WriteEncryptionHeader(dir, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = OpenEncrypted(NewFSStorage(dir), dir, "wrong")
+	if err == nil {
+		t.Fatal("expected error on wrong passphrase")
+	}
+
+	s, err := OpenEncrypted(NewFSStorage(dir), dir, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = New(s).Add("def", "password 1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, err := New(s).Tasks("def")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 || tasks[0].Name != "password 1234" {
+		t.Errorf("unexpected tasks %+v", tasks)
+	}
+
+	plain, err := New(NewFSStorage(dir)).Tasks("def")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plain) != 1 || strings.Contains(plain[0].Name, "1234") {
+		t.Errorf("name expected to be encrypted, got %+v", plain)
+	}
+}
+
+func TestClientImportRejectsInvalidNamespace(t *testing.T) {
+	root := t.TempDir()
+	c := New(NewFSStorage(path.Join(root, "data")))
+
+	imports := map[string]func() (ImportResult, error){
+		"markdown": func() (ImportResult, error) {
+			return c.ImportMarkdown(strings.NewReader("# ../escaped\n\n## pwned\n"), "def")
+		},
+		"todotxt": func() (ImportResult, error) {
+			return c.ImportTodoTxt(strings.NewReader("pwned +../escaped\n"), "def")
+		},
+		"ics": func() (ImportResult, error) {
+			return c.ImportIcs(strings.NewReader("BEGIN:VTODO\nSUMMARY:pwned\nCATEGORIES:../escaped\nEND:VTODO\n"), "def")
+		},
+		"taskwarrior": func() (ImportResult, error) {
+			return c.ImportTaskwarrior(strings.NewReader(`[{"description":"pwned","project":"../escaped","uuid":"a"}]`), "def")
+		},
+	}
+
+	for format, importTasks := range imports {
+		_, err := importTasks()
+		if err == nil {
+			t.Errorf("%s: expected error on namespace '../escaped'", format)
+		}
+	}
+
+	_, err := os.Stat(path.Join(root, "escaped"))
+	if err == nil {
+		t.Errorf("import created directory outside data directory")
+	}
+}
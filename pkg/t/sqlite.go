@@ -0,0 +1,18 @@
+//go:build tsqlite
+
+package t
+
+import (
+	"path"
+
+	storage "github.com/thek4n/t/internal/storage"
+)
+
+// Opens sqlite database of command line tool in directory dir, creates it if not exists
+func OpenSqlite(dir string) (Storage, error) {
+	s, err := storage.OpenSqlite(path.Join(dir, storage.SQLITE_DB_FILE), storage.SORT_UPDATED)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}